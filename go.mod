@@ -6,7 +6,7 @@ toolchain go1.24.7
 
 require (
 	github.com/emicklei/dot v1.9.1
-	github.com/mark3labs/mcp-go v0.41.1
+	github.com/mark3labs/mcp-go v0.44.0
 	golang.org/x/tools v0.28.0
 )
 
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// RequireToken wraps next so that every request must present one of tokens,
+// either as "Authorization: Bearer <token>" or as "X-API-Key: <token>".
+// Without tokens next is returned unchanged.
+func RequireToken(tokens []string, next http.Handler) http.Handler {
+	var sums [][sha256.Size]byte
+	for _, t := range tokens {
+		if t != "" {
+			sums = append(sums, sha256.Sum256([]byte(t)))
+		}
+	}
+	if len(sums) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if presented, ok := requestToken(r); ok {
+			// Compare digests in constant time so neither content nor length leaks
+			sum := sha256.Sum256([]byte(presented))
+			for _, want := range sums {
+				if subtle.ConstantTimeCompare(sum[:], want[:]) == 1 {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="callgraph-mcp"`)
+		http.Error(w, "Unauthorized: missing or invalid bearer token or API key", http.StatusUnauthorized)
+	})
+}
+
+// requestToken extracts the credential of r, if any
+func requestToken(r *http.Request) (string, bool) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, true
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
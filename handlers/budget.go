@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxListedPackages bounds the package names quoted in a budget note
+const maxListedPackages = 10
+
+// fitsBudget reports whether result, with its notes, respects max_nodes and max_output_bytes
+func (a *analysis) fitsBudget(result *graphResult) bool {
+	if a.opts.maxNodes > 0 && result.stats.NodeCount > a.opts.maxNodes {
+		return false
+	}
+	if a.opts.maxOutputBytes > 0 && a.outputSize(result) > a.opts.maxOutputBytes {
+		return false
+	}
+	return true
+}
+
+// outputSize measures the text the client receives for result: the
+// requested output format with the warnings, notes and request echo. The
+// duration is not known yet, so the widest one is reserved.
+func (a *analysis) outputSize(result *graphResult) int {
+	measured := *result
+	measured.stats.DurationMs = math.MaxInt32
+	content, err := renderOutput(a.opts.outputFormat, &measured, newCallgraphResponse(a, a.req, &measured))
+	if err != nil {
+		return math.MaxInt
+	}
+	size := 0
+	for _, c := range content {
+		size += len(c.(mcp.TextContent).Text)
+	}
+	return size
+}
+
+// budgetName describes the budget in force, as set in the request
+func (a *analysis) budgetName() string {
+	var parts []string
+	if a.opts.maxNodes > 0 {
+		parts = append(parts, fmt.Sprintf("max_nodes %d", a.opts.maxNodes))
+	}
+	if a.opts.maxOutputBytes > 0 {
+		parts = append(parts, fmt.Sprintf("max_output_bytes %d", a.opts.maxOutputBytes))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fitBudget degrades a graph that exceeds the output budget. It tries in turn
+// to collapse the least central packages into single nodes (the most central
+// one stays expanded), to also prune the leaf functions beyond a depth, and
+// finally switches to the package-level view. Each step adds a note telling
+// what was elided and how to request it.
+func (a *analysis) fitBudget(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge, keyOf func(*MCPCallgraphNode) string) *graphResult {
+	budget := a.budgetName()
+	pkgs := packagesByCentrality(nodeMap, edgeMap)
+	if len(pkgs) == 0 {
+		// An empty graph cannot shrink any further
+		r := a.renderGraph(collapseNodes(nodeMap, edgeMap, keyOf))
+		r.notes = append(r.notes, fmt.Sprintf("output budget (%s): the empty graph still exceeds the budget; raise the budget or check moduleArgs and limit_prefix", budget))
+		return r
+	}
+	central := pkgs[len(pkgs)-1]
+	collapseFirst := func(k int) (map[string]*MCPCallgraphNode, map[string]*MCPCallgraphEdge) {
+		collapsed := make(map[string]bool, k)
+		for _, p := range pkgs[:k] {
+			collapsed[p] = true
+		}
+		return collapseNodes(nodeMap, edgeMap, func(n *MCPCallgraphNode) string {
+			if collapsed[n.PackagePath] {
+				return n.PackagePath
+			}
+			return keyOf(n)
+		})
+	}
+	collapseNote := func(k int) string {
+		return fmt.Sprintf("output budget (%s): collapsed %d of %d packages into single nodes: %s; request one in detail with limit_prefix [%q]",
+			budget, k, len(pkgs), listPackages(pkgs[:k]), pkgs[0])
+	}
+
+	if a.opts.granularity != GranularityPkg {
+		// Collapsing more packages only shrinks the graph: find the fewest that fit
+		var best *graphResult
+		for lo, hi := 1, len(pkgs)-1; lo <= hi; {
+			mid := (lo + hi) / 2
+			r := a.renderGraph(collapseFirst(mid))
+			r.notes = append(r.notes, collapseNote(mid))
+			if a.fitsBudget(r) {
+				best = r
+				hi = mid - 1
+			} else {
+				lo = mid + 1
+			}
+		}
+		if best != nil {
+			return best
+		}
+
+		k := len(pkgs) - 1
+		nodes, edges := collapseFirst(k)
+		depths := graphDepths(nodes, edges)
+		maxDepth := 0
+		for _, d := range depths {
+			if d > maxDepth {
+				maxDepth = d
+			}
+		}
+		for depth := maxDepth - 1; depth >= 1; depth-- {
+			prunedNodes, prunedEdges, count, parent := pruneLeaves(nodes, edges, depths, depth)
+			if count == 0 {
+				continue
+			}
+			r := a.renderGraph(prunedNodes, prunedEdges)
+			if k > 0 {
+				r.notes = append(r.notes, collapseNote(k))
+			}
+			note := fmt.Sprintf("output budget (%s): pruned %d leaf functions deeper than %d calls", budget, count, depth)
+			if parent != "" {
+				note += fmt.Sprintf("; see them with symbol %q and direction downstream", parent)
+			}
+			r.notes = append(r.notes, note)
+			if a.fitsBudget(r) {
+				return r
+			}
+		}
+	}
+
+	nodes, edges := collapseGraph(nodeMap, edgeMap, GranularityPkg)
+	r := a.renderGraphAs(nodes, edges, GranularityPkg)
+	if a.opts.granularity != GranularityPkg {
+		r.notes = append(r.notes, fmt.Sprintf("output budget (%s): switched to the package-level view (granularity pkg); request one package in detail with granularity func and limit_prefix [%q]", budget, central))
+	}
+	if !a.fitsBudget(r) {
+		r.notes = append(r.notes, fmt.Sprintf("output budget (%s): the package-level view still exceeds the budget; narrow moduleArgs or set limit_prefix", budget))
+	}
+	return r
+}
+
+// packagesByCentrality returns the packages of the graph, least central
+// first: by number of incident edges, larger packages first on ties
+func packagesByCentrality(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge) []string {
+	degree := make(map[string]int)
+	size := make(map[string]int)
+	for _, n := range nodeMap {
+		size[n.PackagePath]++
+	}
+	for _, ed := range edgeMap {
+		if n, ok := nodeMap[ed.Caller]; ok {
+			degree[n.PackagePath]++
+		}
+		if n, ok := nodeMap[ed.Callee]; ok {
+			degree[n.PackagePath]++
+		}
+	}
+	pkgs := make([]string, 0, len(size))
+	for p := range size {
+		pkgs = append(pkgs, p)
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		pi, pj := pkgs[i], pkgs[j]
+		if degree[pi] != degree[pj] {
+			return degree[pi] < degree[pj]
+		}
+		if size[pi] != size[pj] {
+			return size[pi] > size[pj]
+		}
+		return pi < pj
+	})
+	return pkgs
+}
+
+// graphDepths returns the call depth of each node reachable from the nodes
+// without callers
+func graphDepths(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge) map[string]int {
+	out := make(map[string][]string)
+	hasCaller := make(map[string]bool)
+	for _, ed := range sortedEdges(edgeMap) {
+		if ed.Caller == ed.Callee {
+			continue
+		}
+		out[ed.Caller] = append(out[ed.Caller], ed.Callee)
+		hasCaller[ed.Callee] = true
+	}
+	depths := make(map[string]int)
+	var queue []string
+	for _, id := range sortedNodeIDs(nodeMap) {
+		if !hasCaller[id] {
+			depths[id] = 0
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, callee := range out[id] {
+			if _, seen := depths[callee]; !seen {
+				depths[callee] = depths[id] + 1
+				queue = append(queue, callee)
+			}
+		}
+	}
+	return depths
+}
+
+// pruneLeaves drops the function nodes without callees deeper than depth.
+// It returns the pruned graph, the number of dropped nodes and the caller
+// that lost the most of them.
+func pruneLeaves(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge, depths map[string]int, depth int) (map[string]*MCPCallgraphNode, map[string]*MCPCallgraphEdge, int, string) {
+	hasCallee := make(map[string]bool)
+	for _, ed := range edgeMap {
+		if ed.Caller != ed.Callee {
+			hasCallee[ed.Caller] = true
+		}
+	}
+	pruned := make(map[string]bool)
+	nodes := make(map[string]*MCPCallgraphNode, len(nodeMap))
+	for id, n := range nodeMap {
+		if d, ok := depths[id]; ok && d > depth && !hasCallee[id] && n.Members == 0 {
+			pruned[id] = true
+			continue
+		}
+		nodes[id] = n
+	}
+	edges := make(map[string]*MCPCallgraphEdge, len(edgeMap))
+	lost := make(map[string]int)
+	for id, ed := range edgeMap {
+		if pruned[ed.Callee] {
+			if n := nodeMap[ed.Caller]; n != nil && n.Members == 0 && !pruned[ed.Caller] {
+				lost[ed.Caller]++
+			}
+			continue
+		}
+		if pruned[ed.Caller] {
+			continue
+		}
+		edges[id] = ed
+	}
+	parent := ""
+	for _, id := range sortedNodeIDs(nodeMap) {
+		if lost[id] > lost[parent] {
+			parent = id
+		}
+	}
+	return nodes, edges, len(pruned), parent
+}
+
+// listPackages quotes up to maxListedPackages package paths
+func listPackages(pkgs []string) string {
+	if len(pkgs) <= maxListedPackages {
+		return strings.Join(pkgs, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(pkgs[:maxListedPackages], ", "), len(pkgs)-maxListedPackages)
+}
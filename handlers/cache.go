@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+)
+
+// defaultCacheSize is the number of analyzed programs kept in memory.
+// SSA programs are large, so keep this small.
+const defaultCacheSize = 4
+
+// analysisCache keeps built SSA programs and callgraphs between requests
+var analysisCache = newCache(defaultCacheSize)
+
+// SetAnalysisCacheSize sets how many analyzed programs are kept in memory (0 disables caching)
+func SetAnalysisCacheSize(n int) {
+	analysisCache.mu.Lock()
+	defer analysisCache.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	analysisCache.max = n
+	analysisCache.evictLocked()
+}
+
+// cacheKey identifies an analysis by everything that affects packages.Load and the callgraph algorithm
+type cacheKey struct {
+	dir   string
+	args  string
+	tags  string
+	tests bool
+	algo  CallGraphType
+	roots string // RTA root selection (also seeding VTA), empty for other algorithms
+	seed  string // VTA seed algorithm
+}
+
+// fileStamp records the state of a watched file or directory
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+	hash    string // content hash, only for go.mod/go.sum/go.work
+}
+
+// cacheEntry is a built analysis shared by all requests with the same key
+type cacheEntry struct {
+	ready    chan struct{}
+	err      error
+	prog     *ssa.Program
+	pkgs     []*ssa.Package
+	mainPkg  *ssa.Package
+	graph    *callgraph.Graph
+	roots    []*ssa.Function
+	warnings []string
+	stamps   map[string]fileStamp
+	lastUsed time.Time
+}
+
+type cache struct {
+	mu      sync.Mutex
+	max     int
+	entries map[cacheKey]*cacheEntry
+}
+
+func newCache(max int) *cache {
+	return &cache{max: max, entries: make(map[cacheKey]*cacheEntry)}
+}
+
+// newCacheKey normalizes request parameters into a cache key
+func newCacheKey(algo CallGraphType, dir string, tests bool, args []string, tags []string, opts *renderOpts) cacheKey {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	var roots, seed string
+	if algo == CallGraphTypeVta {
+		seed = opts.vtaSeed
+		if seed == "" {
+			seed = VTASeedCHA
+		}
+	}
+	if algo == CallGraphTypeRta || seed == VTASeedRTA {
+		roots = opts.rtaRoots + "\x00" + strings.Join(opts.roots, "\x00")
+	}
+	return cacheKey{
+		dir:   dir,
+		args:  strings.Join(args, "\x00"),
+		tags:  strings.Join(tags, ","),
+		tests: tests,
+		algo:  algo,
+		roots: roots,
+		seed:  seed,
+	}
+}
+
+// LoadAnalysis fills a with a cached analysis for the given parameters,
+// running DoAnalysis when there is none, it is stale or refresh is set.
+func (a *analysis) LoadAnalysis(
+	ctx context.Context,
+	algo CallGraphType,
+	dir string,
+	tests bool,
+	args []string,
+	tags []string,
+	refresh bool,
+) error {
+	key := newCacheKey(algo, dir, tests, args, tags, a.opts)
+	e, err := analysisCache.load(ctx, key, refresh, func(e *cacheEntry) error {
+		built := &analysis{opts: a.opts}
+		if err := built.DoAnalysis(ctx, algo, dir, tests, args, tags); err != nil {
+			return err
+		}
+		e.prog = built.prog
+		e.pkgs = built.pkgs
+		e.mainPkg = built.mainPkg
+		e.graph = built.callgraph
+		e.roots = built.roots
+		e.warnings = built.warnings
+		e.stamps = built.stamps
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	a.prog = e.prog
+	a.pkgs = e.pkgs
+	a.mainPkg = e.mainPkg
+	a.callgraph = e.graph
+	a.roots = e.roots
+	a.warnings = e.warnings
+	a.stamps = e.stamps
+	return nil
+}
+
+// load returns the cached analysis for key, building it with build when it
+// is missing, stale or refresh is requested. Concurrent requests for the same
+// key wait for a single build; a waiter whose ctx ends stops waiting, and a
+// build cancelled by its own request is retried by the next waiter.
+func (c *cache) load(ctx context.Context, key cacheKey, refresh bool, build func(e *cacheEntry) error) (*cacheEntry, error) {
+	for {
+		c.mu.Lock()
+		e, ok := c.entries[key]
+		if ok && !refresh {
+			c.mu.Unlock()
+			select {
+			case <-e.ready:
+			case <-ctx.Done():
+				return nil, checkContext(ctx, "waiting for a concurrent analysis")
+			}
+			if e.err == nil && e.fresh() {
+				c.mu.Lock()
+				e.lastUsed = time.Now()
+				c.mu.Unlock()
+				ctxLogf(ctx, "analysis cache hit")
+				return e, nil
+			}
+			// Stale or failed: drop it (unless someone already replaced it) and rebuild
+			c.mu.Lock()
+			if c.entries[key] == e {
+				delete(c.entries, key)
+			}
+			c.mu.Unlock()
+			continue
+		}
+
+		e = &cacheEntry{ready: make(chan struct{}), lastUsed: time.Now()}
+		if c.max > 0 {
+			c.entries[key] = e
+			c.evictLocked()
+		}
+		c.mu.Unlock()
+
+		ctxLogf(ctx, "analysis cache miss (refresh: %v)", refresh)
+		e.err = build(e)
+		close(e.ready)
+		if e.err != nil {
+			c.mu.Lock()
+			if c.entries[key] == e {
+				delete(c.entries, key)
+			}
+			c.mu.Unlock()
+			return nil, e.err
+		}
+		return e, nil
+	}
+}
+
+// lookup returns the most recently used fresh analysis of dir and args with
+// any algorithm, or nil when there is none. It never builds one.
+func (c *cache) lookup(dir string, args []string) *cacheEntry {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	joined := strings.Join(args, "\x00")
+	c.mu.Lock()
+	var found []*cacheEntry
+	for k, e := range c.entries {
+		if k.dir != dir || k.args != joined {
+			continue
+		}
+		select {
+		case <-e.ready:
+		default:
+			continue // still building
+		}
+		if e.err == nil {
+			found = append(found, e)
+		}
+	}
+	c.mu.Unlock()
+	sort.Slice(found, func(i, j int) bool { return found[i].lastUsed.After(found[j].lastUsed) })
+	// Stat the files outside the lock; stale entries are rebuilt by load
+	for _, e := range found {
+		if e.fresh() {
+			return e
+		}
+	}
+	return nil
+}
+
+// evictLocked drops least recently used entries above the size limit
+func (c *cache) evictLocked() {
+	for len(c.entries) > c.max {
+		var oldestKey cacheKey
+		var oldest *cacheEntry
+		for k, e := range c.entries {
+			if oldest == nil || e.lastUsed.Before(oldest.lastUsed) {
+				oldestKey, oldest = k, e
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+}
+
+// fresh reports whether none of the watched files changed since the entry was built
+func (e *cacheEntry) fresh() bool {
+	for path, old := range e.stamps {
+		cur, ok := stampFile(path, old.hash != "")
+		if !ok || cur != old {
+			logf("analysis cache invalidated by %s", path)
+			return false
+		}
+	}
+	return true
+}
+
+// stampFiles records the current state of each path; missing paths are
+// recorded as zero stamps so that their creation invalidates the entry.
+func stampFiles(paths []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, p := range paths {
+		st, _ := stampFile(p, isModFile(p))
+		stamps[p] = st
+	}
+	return stamps
+}
+
+func stampFile(path string, withHash bool) (fileStamp, bool) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, os.IsNotExist(err)
+	}
+	st := fileStamp{size: fi.Size(), modTime: fi.ModTime()}
+	if withHash {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fileStamp{}, false
+		}
+		sum := sha256.Sum256(data)
+		st.hash = hex.EncodeToString(sum[:])
+	}
+	return st, true
+}
+
+func isModFile(path string) bool {
+	switch filepath.Base(path) {
+	case "go.mod", "go.sum", "go.work", "go.work.sum":
+		return true
+	}
+	return false
+}
+
+// watchedFiles lists the files whose changes invalidate an analysis: source
+// files and directories of every non-std package outside the module cache,
+// plus go.mod/go.sum of their modules and of the working directory.
+func watchedFiles(dir string, initial []*packages.Package) []string {
+	set := make(map[string]bool)
+	addModFiles := func(d string) {
+		for _, name := range []string{"go.mod", "go.sum", "go.work", "go.work.sum"} {
+			set[filepath.Join(d, name)] = true
+		}
+	}
+
+	if abs, err := filepath.Abs(dir); err == nil {
+		set[abs] = true
+		// Walk up to the enclosing module root
+		for d := abs; ; d = filepath.Dir(d) {
+			if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+				addModFiles(d)
+				break
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+
+	packages.Visit(initial, nil, func(p *packages.Package) {
+		if p.Module == nil {
+			if isStdPkgPath(p.PkgPath) {
+				return
+			}
+		} else if !p.Module.Main && p.Module.Replace == nil {
+			// Module cache contents are immutable and pinned by go.sum
+			return
+		}
+		var modDir string
+		if p.Module != nil {
+			modDir = p.Module.Dir
+			if p.Module.Replace != nil {
+				modDir = p.Module.Replace.Dir
+			}
+			if modDir != "" {
+				addModFiles(modDir)
+			}
+		}
+		files := append(append([]string{}, p.GoFiles...), p.OtherFiles...)
+		files = append(files, p.EmbedFiles...)
+		for _, f := range files {
+			set[f] = true
+			// Watch the package directory and its parents inside the module
+			// so that added or removed files are noticed too.
+			for d := filepath.Dir(f); !set[d]; d = filepath.Dir(d) {
+				set[d] = true
+				if modDir == "" || d == modDir || !strings.HasPrefix(d, modDir) || filepath.Dir(d) == d {
+					break
+				}
+			}
+		}
+	})
+
+	paths := make([]string, 0, len(set))
+	for p := range set {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -2,23 +2,24 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"go/build"
 	"go/token"
 	"go/types"
 	"log"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/emicklei/dot"
 	"github.com/mark3labs/mcp-go/mcp"
 	"golang.org/x/tools/go/callgraph"
 	"golang.org/x/tools/go/callgraph/cha"
 	"golang.org/x/tools/go/callgraph/rta"
 	"golang.org/x/tools/go/callgraph/static"
+	"golang.org/x/tools/go/callgraph/vta"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/ssa"
 	"golang.org/x/tools/go/ssa/ssautil"
@@ -31,20 +32,37 @@ const (
 	CallGraphTypeStatic CallGraphType = "static"
 	CallGraphTypeCha    CallGraphType = "cha"
 	CallGraphTypeRta    CallGraphType = "rta"
+	CallGraphTypeVta    CallGraphType = "vta"
+)
+
+// Supported values of the vta_seed parameter: the callgraph VTA refines
+const (
+	VTASeedCHA = "cha"
+	VTASeedRTA = "rta"
 )
 
 type renderOpts struct {
-	cacheDir string
-	focus    string
-	group    []string
-	ignore   []string
-	include  []string
-	limit    []string
-	nointer  bool
-	refresh  bool
-	nostd    bool
-	algo     CallGraphType
-	maxDep   int
+	focus       string
+	group       []string
+	ignore      []string
+	include     []string
+	limit       []string
+	nointer     bool
+	refresh     bool
+	nostd       bool
+	algo        CallGraphType
+	maxDep      int
+	maxDepUp    int      // upstream depth limit for symbol traversal
+	maxDepDown  int      // downstream depth limit for symbol traversal
+	rtaRoots    string   // RTA root selection mode
+	roots       []string // explicit RTA roots
+	vtaSeed     string   // initial callgraph refined by VTA
+	edgeKinds   []string // call kinds of the edges to keep (empty: all)
+	callCounts  bool     // label Mermaid edges with their number of call sites
+	granularity string   // node granularity: func, type or pkg
+	maxNodes    int      // output budget in nodes (0: unlimited)
+	maxOutputBytes int   // output budget in bytes of the requested format (0: unlimited)
+	outputFormat string  // requested output format, to measure the output budget
 }
 
 type analysis struct {
@@ -53,26 +71,46 @@ type analysis struct {
 	pkgs      []*ssa.Package
 	mainPkg   *ssa.Package
 	callgraph *callgraph.Graph
+	roots     []*ssa.Function      // RTA entry points, not counting package initializers
+	warnings  []string             // analysis caveats reported with the output
+	stamps    map[string]fileStamp // watched files at load time, for cache invalidation
+	req       MCPCallgraphRequest  // request being answered, echoed in the measured output
 }
 
 // MCPCallgraphRequest represents the input parameters for the callgraph tool via MCP
 type MCPCallgraphRequest struct {
-	ModuleArgs []string `json:"moduleArgs"`
-	Dir        string   `json:"dir,omitempty"`
-	Focus      string   `json:"focus,omitempty"`
-	Group      []string `json:"group,omitempty"`
-	LimitKeyword []string `json:"limit_keyword,omitempty"`
-	LimitPrefix  []string `json:"limit_prefix,omitempty"`
-	Ignore     []string `json:"ignore,omitempty"`
-	NoStd      bool     `json:"nostd,omitempty"`
-	NoInter    bool     `json:"nointer,omitempty"`
-	Tests      bool     `json:"tests,omitempty"`
-	Algo       string   `json:"algo,omitempty"`
-	Tags       []string `json:"tags,omitempty"`
-	Debug      bool     `json:"debug,omitempty"`
-	Symbol    string `json:"symbol,omitempty"`
-	Direction string `json:"direction,omitempty"`
-	MaxDep    int    `json:"max_dep,omitempty"`
+	ModuleArgs       []string `json:"moduleArgs"`
+	Dir              string   `json:"dir,omitempty"`
+	Focus            string   `json:"focus,omitempty"`
+	Group            []string `json:"group,omitempty"`
+	LimitKeyword     []string `json:"limit_keyword,omitempty"`
+	LimitPrefix      []string `json:"limit_prefix,omitempty"`
+	Ignore           []string `json:"ignore,omitempty"`
+	NoStd            bool     `json:"nostd,omitempty"`
+	NoInter          bool     `json:"nointer,omitempty"`
+	Tests            bool     `json:"tests,omitempty"`
+	Algo             string   `json:"algo,omitempty"`
+	RtaRoots         string   `json:"rta_roots,omitempty"`
+	Roots            []string `json:"roots,omitempty"`
+	VTASeed          string   `json:"vta_seed,omitempty"`
+	EdgeKinds        []string `json:"edge_kinds,omitempty"`
+	CallCounts       bool     `json:"call_counts,omitempty"`
+	Granularity      string   `json:"granularity,omitempty"`
+	MaxNodes         int      `json:"max_nodes,omitempty"`
+	MaxOutputBytes   int      `json:"max_output_bytes,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+	Debug            bool     `json:"debug,omitempty"`
+	Symbol           string   `json:"symbol,omitempty"`
+	File             string   `json:"file,omitempty"`
+	Line             int      `json:"line,omitempty"`
+	Column           int      `json:"column,omitempty"`
+	Direction        string   `json:"direction,omitempty"`
+	MaxDep           int      `json:"max_dep,omitempty"`
+	MaxDepUpstream   int      `json:"max_dep_upstream,omitempty"`
+	MaxDepDownstream int      `json:"max_dep_downstream,omitempty"`
+	Refresh          bool     `json:"refresh,omitempty"`
+	TimeoutMs        int      `json:"timeout_ms,omitempty"`
+	OutputFormat     string   `json:"output_format,omitempty"`
 }
 
 // MCPCallgraphResponse represents the output of the callgraph tool via MCP
@@ -82,16 +120,25 @@ type MCPCallgraphResponse struct {
 	Filters   MCPCallgraphFilters    `json:"filters"`
 	Stats     MCPCallgraphStats      `json:"stats"`
 	Graph     MCPCallgraphData       `json:"graph"`
+	Warnings  []string               `json:"warnings,omitempty"`
+	Notes     []string               `json:"notes,omitempty"`
 	Error     string                 `json:"error,omitempty"`
 }
 
 type MCPCallgraphFilters struct {
-	Limit   []string `json:"limit_keyword"`
-	Ignore  []string `json:"ignore"`
-	Include []string `json:"limit_prefix"`
-	NoStd   bool     `json:"nostd"`
-	NoInter bool     `json:"nointer"`
-	Group   []string `json:"group"`
+	Limit       []string `json:"limit_keyword"`
+	Ignore      []string `json:"ignore"`
+	Include     []string `json:"limit_prefix"`
+	NoStd       bool     `json:"nostd"`
+	NoInter     bool     `json:"nointer"`
+	Group       []string `json:"group"`
+	MaxDep      int      `json:"max_dep"`
+	MaxDepUp    int      `json:"max_dep_upstream,omitempty"`
+	MaxDepDown  int      `json:"max_dep_downstream,omitempty"`
+	Symbol      string   `json:"symbol,omitempty"`
+	Direction   string   `json:"direction,omitempty"`
+	EdgeKinds   []string `json:"edge_kinds,omitempty"`
+	Granularity string   `json:"granularity,omitempty"`
 }
 
 type MCPCallgraphStats struct {
@@ -115,14 +162,30 @@ type MCPCallgraphNode struct {
 	IsStd        bool    `json:"isStd"`
 	Exported     bool    `json:"exported"`
 	ReceiverType *string `json:"receiverType"`
+	MoreCallees  int     `json:"moreCallees,omitempty"` // callees hidden by the depth limit
+	MoreCallers  int     `json:"moreCallers,omitempty"` // callers hidden by the depth limit
+	Members      int     `json:"members,omitempty"`     // functions aggregated into a pkg or type node
 }
 
 type MCPCallgraphEdge struct {
-	Caller    string `json:"caller"`
-	Callee    string `json:"callee"`
-	File      string `json:"file"`
-	Line      int    `json:"line"`
-	Synthetic bool   `json:"synthetic"`
+	Caller    string        `json:"caller"`
+	Callee    string        `json:"callee"`
+	File      string        `json:"file"` // primary (first in source order) call site
+	Line      int           `json:"line"`
+	Synthetic bool          `json:"synthetic"`
+	Kind      string        `json:"kind,omitempty"`  // call kind of the primary site: static, interface, closure, go or defer (mixed when aggregated calls differ)
+	Algorithm string        `json:"algorithm"`       // algorithm that resolved the call
+	Count     int           `json:"count"`           // number of call sites
+	Sites     []MCPCallSite `json:"sites,omitempty"` // every call site, in source order (none when aggregated)
+	Cycle     bool          `json:"cycle,omitempty"` // on the representative cycle reported by findCycles
+}
+
+// MCPCallSite is one call instruction behind an edge
+type MCPCallSite struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Kind   string `json:"kind"`
 }
 
 // HandleCallgraphRequest processes the MCP callgraph request
@@ -131,152 +194,134 @@ func HandleCallgraphRequest(ctx context.Context, request mcp.CallToolRequest) (*
 	
 	// Parse the arguments
 	var req MCPCallgraphRequest
-	
-	// Convert arguments to JSON bytes first
-	argsBytes, err := json.Marshal(request.Params.Arguments)
-	if err != nil {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf("Error marshaling arguments: %v", err)),
-			},
-			IsError: true,
-		}, nil
-	}
-	
-	if err := json.Unmarshal(argsBytes, &req); err != nil {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf("Error parsing arguments: %v", err)),
-			},
-			IsError: true,
-		}, nil
+	if err := decodeArguments(request, &req); err != nil {
+		return errorResult("%v", err), nil
 	}
 
 	// Validate required parameters
 	if len(req.ModuleArgs) == 0 {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent("Error: moduleArgs is required"),
-			},
-			IsError: true,
-		}, nil
+		return errorResult("Error: moduleArgs is required"), nil
 	}
-	// Unified tool: when symbol is provided, perform directional traversal; otherwise, generate package-level callgraph
-	// Set defaults
-	if req.Algo == "" {
-	    req.Algo = "rta"
+	if !isValidOutputFormat(req.OutputFormat) {
+		return errorResult("Error: invalid output_format %q (expected one of %s)", req.OutputFormat, strings.Join(outputFormats, ", ")), nil
 	}
-	if len(req.Group) == 0 {
-	    req.Group = []string{"pkg"}
+	if err := validateEdgeKinds(req.EdgeKinds); err != nil {
+		return errorResult("Error: %v", err), nil
 	}
-
-	// Set debug flag
-	if req.Debug {
-	    *debugFlag = true
+	if !isValidGranularity(req.Granularity) {
+		return errorResult("Error: invalid granularity %q (expected one of %s)", req.Granularity, strings.Join(granularities, ", ")), nil
 	}
-
-	// Apply default values for boolean fields to match schema defaults
-	// Note: Go's zero value for bool is false, but our schema defaults are different
-	if args, ok := request.Params.Arguments.(map[string]interface{}); ok {
-	    // Check if nostd was explicitly provided in the request
-	    if _, exists := args["nostd"]; !exists { req.NoStd = true }
-	    // Check if nointer was explicitly provided in the request
-	    if _, exists := args["nointer"]; !exists { req.NoInter = true }
-	    // Dynamic default for max_dep depending on symbol presence
-	    if _, exists := args["max_dep"]; !exists {
-	        if req.Symbol != "" { req.MaxDep = 7 } else { req.MaxDep = 4 }
-	    }
+	if req.Symbol != "" && req.File != "" {
+		return errorResult("Error: symbol and file are mutually exclusive"), nil
 	}
-	// Map MCP request to internal analysis options
-	opts := mapMCPRequestToRenderOpts(req)
-	
-	// Set up build tags if provided
-	if len(req.Tags) > 0 {
-		build.Default.BuildTags = req.Tags
+	if req.File != "" && req.Line <= 0 {
+		return errorResult("Error: line is required with file"), nil
 	}
+	if req.Column < 0 {
+		return errorResult("Error: invalid column %d (expected a 1-based byte column)", req.Column), nil
+	}
+	// Unified tool: when symbol (or file and line) is provided, perform directional traversal; otherwise, generate package-level callgraph
+	req.applyDefaults(request)
 
-	// Initialize analysis
-	analysis := &analysis{opts: opts}
-	
-	// Perform analysis
-	algo := CallGraphType(req.Algo)
-	if err := analysis.DoAnalysis(algo, req.Dir, req.Tests, req.ModuleArgs); err != nil {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf("Analysis failed: %v", err)),
-			},
-			IsError: true,
-		}, nil
+	ctx = withProgress(ctx, request)
+	ctx, cancel := withRequestTimeout(ctx, req)
+	defer cancel()
+
+	analysis, result, err := generateCallgraph(ctx, &req, start)
+	if err != nil {
+		return errorResult("%v", err), nil
+	}
+
+	content, err := renderOutput(req.OutputFormat, result, newCallgraphResponse(analysis, req, result))
+	if err != nil {
+		return errorResult("Error rendering output: %v", err), nil
 	}
+	// Link the graph resources so clients can re-read them later
+	content = append(content, registerGraphResources(ctx, req)...)
+	return &mcp.CallToolResult{Content: content}, nil
+}
 
-	// Process list arguments (comma-separated strings to slices)
-	if err := analysis.ProcessListArgs(); err != nil {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf("Error processing arguments: %v", err)),
-			},
-			IsError: true,
-		}, nil
+// generateCallgraph loads the analysis of req and builds its filtered graph:
+// a traversal when symbol (or file and line) is set, the whole callgraph
+// otherwise. The resolved symbol and the default direction are stored in req.
+func generateCallgraph(ctx context.Context, req *MCPCallgraphRequest, start time.Time) (*analysis, *graphResult, error) {
+	analysis, err := newRequestAnalysis(ctx, *req)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Generate Mermaid output
-	var mermaidCode string
-	var stats MCPCallgraphStats
-	if req.Symbol != "" {
+	// Generate the filtered graph and its Mermaid rendering
+	var result *graphResult
+	if req.Symbol != "" || req.File != "" {
 		// Default direction
 		dir := req.Direction
 		if dir == "" {
 			dir = "downstream"
 		}
-		m, s, err := generateMermaidTraversal(analysis, req.Symbol, dir)
+		req.Direction = dir
+		var startNode *callgraph.Node
+		if req.File != "" {
+			startNode, err = analysis.findPositionNode(req.Dir, req.File, req.Line, req.Column)
+		} else {
+			startNode, err = analysis.findSymbolNode(req.Symbol)
+		}
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf("Error generating symbol traversal: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			return nil, nil, fmt.Errorf("Error generating symbol traversal: %v", err)
 		}
-		mermaidCode, stats = m, s
+		if req.Symbol == "" {
+			// Report the function resolved from the position as the traversal symbol
+			req.Symbol = startNode.Func.String()
+		}
+		analysis.req = *req
+		r, err := generateMermaidTraversal(analysis, startNode, dir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error generating symbol traversal: %v", err)
+		}
+		result = r
 	} else {
-		m, s, err := generateMermaidCallgraph(analysis)
+		analysis.req = *req
+		r, err := generateMermaidCallgraph(analysis)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf("Error generating callgraph: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			return nil, nil, fmt.Errorf("Error generating callgraph: %v", err)
 		}
-		mermaidCode, stats = m, s
+		result = r
 	}
 
-	// Calculate duration (optional usage)
+	if err := checkContext(ctx, phaseFilter); err != nil {
+		return nil, nil, err
+	}
+	progressf(ctx, stepRender, "filtered graph has %d nodes and %d edges; rendering", result.stats.NodeCount, result.stats.EdgeCount)
+
+	// Calculate duration
 	duration := time.Since(start)
-	stats.DurationMs = int(duration.Milliseconds())
-
-	// Return Mermaid flowchart code directly
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.NewTextContent(mermaidCode),
-		},
-	}, nil
+	result.stats.DurationMs = int(duration.Milliseconds())
+	return analysis, result, nil
 }
 
 // mapMCPRequestToRenderOpts converts MCP request to internal renderOpts
 func mapMCPRequestToRenderOpts(req MCPCallgraphRequest) *renderOpts {
 	return &renderOpts{
-		cacheDir: "",
 		focus:    req.Focus,
 		group:    req.Group,
 		ignore:   req.Ignore,
 		include:  req.LimitPrefix,
 		limit:    req.LimitKeyword,
 		nointer:  req.NoInter,
-		refresh:  false,
+		refresh:  req.Refresh,
 		nostd:    req.NoStd,
 		algo:     CallGraphType(req.Algo),
 		maxDep:   req.MaxDep,
+		maxDepUp:   req.MaxDepUpstream,
+		maxDepDown: req.MaxDepDownstream,
+		rtaRoots:   req.RtaRoots,
+		roots:      req.Roots,
+		vtaSeed:    req.VTASeed,
+		edgeKinds:  req.EdgeKinds,
+		callCounts: req.CallCounts,
+		granularity: req.Granularity,
+		maxNodes:   req.MaxNodes,
+		maxOutputBytes: req.MaxOutputBytes,
+		outputFormat: req.OutputFormat,
 	}
 }
 
@@ -325,40 +370,56 @@ func initFuncs(pkgs []*ssa.Package) ([]*ssa.Function, error) {
 }
 
 func (a *analysis) DoAnalysis(
+	ctx context.Context,
 	algo CallGraphType,
 	dir string,
 	tests bool,
 	args []string,
+	tags []string,
 ) error {
-	logf("begin analysis")
-	defer logf("analysis done")
+	ctxLogf(ctx, "begin analysis")
+	defer ctxLogf(ctx, "analysis done")
 
 	cfg := &packages.Config{
-		Mode:       packages.LoadAllSyntax,
+		Mode:       packages.LoadAllSyntax | packages.NeedModule,
+		Context:    ctx,
 		Tests:      tests,
 		Dir:        dir,
-		BuildFlags: getBuildFlags(),
+		BuildFlags: getBuildFlags(tags),
 	}
 
-	logf("loading packages")
+	progressf(ctx, stepLoad, "loading packages %v", args)
 
 	initial, err := packages.Load(cfg, args...)
+	if err := checkContext(ctx, phaseLoad); err != nil {
+		return err
+	}
 	if err != nil {
 		return err
 	}
+	if err := checkLoadedPackages(initial); err != nil {
+		return err
+	}
 	if packages.PrintErrors(initial) > 0 {
 		return fmt.Errorf("packages contain errors")
 	}
 
-	logf("loaded %d initial packages, building program", len(initial))
+	// Snapshot watched files right after loading so edits made during the build invalidate the cache
+	a.stamps = stampFiles(watchedFiles(dir, initial))
 
 	// Create and build SSA-form program representation.
 	mode := ssa.InstantiateGenerics
 	prog, pkgs := ssautil.AllPackages(initial, mode)
 	
-	prog.Build()
+	progressf(ctx, stepBuild, "loaded %d initial packages (%d with dependencies); building SSA", len(initial), len(prog.AllPackages()))
+	if err := buildProgram(ctx, prog); err != nil {
+		return err
+	}
 
-	logf("build done, computing callgraph (algo: %v)", algo)
+	progressf(ctx, stepCallgraph, "built SSA for %d packages; computing callgraph (algo: %v)", len(prog.AllPackages()), algo)
+
+	a.prog = prog
+	a.pkgs = pkgs
 
 	var graph *callgraph.Graph
 	var mainPkg *ssa.Package
@@ -369,38 +430,91 @@ func (a *analysis) DoAnalysis(
 	case CallGraphTypeCha:
 		graph = cha.CallGraph(prog)
 	case CallGraphTypeRta:
-		mains, err := mainPackages(prog.AllPackages())
+		res, main, err := a.analyzeRTA(ctx, prog, pkgs, tests)
 		if err != nil {
 			return err
 		}
-		var roots []*ssa.Function
-		mainPkg = mains[0]
-		for _, main := range mains {
-			roots = append(roots, main.Func("main"))
-		}
-
-		inits, err := initFuncs(prog.AllPackages())
-		if err != nil {
-			return err
-		}
-		for _, init := range inits {
-			roots = append(roots, init)
+		mainPkg = main
+		graph = res.CallGraph
+	case CallGraphTypeVta:
+		switch a.opts.vtaSeed {
+		case "", VTASeedCHA:
+			graph = vta.CallGraph(ssautil.AllFunctions(prog), cha.CallGraph(prog))
+		case VTASeedRTA:
+			res, main, err := a.analyzeRTA(ctx, prog, pkgs, tests)
+			if err != nil {
+				return err
+			}
+			mainPkg = main
+			// VTA only needs to refine the functions RTA found reachable
+			funcs := make(map[*ssa.Function]bool, len(res.Reachable))
+			for fn := range res.Reachable {
+				funcs[fn] = true
+			}
+			graph = vta.CallGraph(funcs, res.CallGraph)
+		default:
+			return fmt.Errorf("invalid vta_seed: %s", a.opts.vtaSeed)
 		}
-
-		graph = rta.Analyze(roots, true).CallGraph
 	default:
 		return fmt.Errorf("invalid call graph type: %s", algo)
 	}
 
-	logf("callgraph resolved with %d nodes", len(graph.Nodes))
+	if err := checkContext(ctx, phaseCallgraph); err != nil {
+		return err
+	}
+
+	// Delete synthetic nodes once here: the graph may be shared by cached requests and must not be mutated afterwards
+	graph.DeleteSyntheticNodes()
+
+	ctxLogf(ctx, "callgraph resolved with %d nodes", len(graph.Nodes))
 
-	a.prog = prog
-	a.pkgs = pkgs
 	a.mainPkg = mainPkg
 	a.callgraph = graph
 	return nil
 }
 
+// analyzeRTA runs RTA from the roots selected by rtaRoots and the package initializers
+func (a *analysis) analyzeRTA(ctx context.Context, prog *ssa.Program, pkgs []*ssa.Package, tests bool) (*rta.Result, *ssa.Package, error) {
+	roots, mainPkg, warnings, err := a.rtaRoots(prog, pkgs, tests)
+	if err != nil {
+		return nil, nil, err
+	}
+	a.roots = roots
+	a.warnings = warnings
+	for _, w := range warnings {
+		ctxLogf(ctx, "warning: %s", w)
+	}
+
+	inits, err := initFuncs(prog.AllPackages())
+	if err != nil {
+		return nil, nil, err
+	}
+	roots = append(roots[:len(roots):len(roots)], inits...)
+	return rta.Analyze(roots, true), mainPkg, nil
+}
+
+// buildProgram builds the SSA code of every package like prog.Build, with the
+// same parallelism, but stops starting packages once ctx is done.
+func buildProgram(ctx context.Context, prog *ssa.Program) error {
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for _, p := range prog.AllPackages() {
+		limit <- struct{}{}
+		if ctx.Err() != nil {
+			<-limit
+			break
+		}
+		wg.Add(1)
+		go func(p *ssa.Package) {
+			defer wg.Done()
+			p.Build()
+			<-limit
+		}(p)
+	}
+	wg.Wait()
+	return checkContext(ctx, phaseBuild)
+}
+
 func (a *analysis) ProcessListArgs() (e error) {
 	var groupBy []string
 	var ignorePaths []string
@@ -452,8 +566,8 @@ func (a *analysis) ProcessListArgs() (e error) {
 	return
 }
 
-func getBuildFlags() []string {
-	buildFlagTags := getBuildFlagTags(build.Default.BuildTags)
+func getBuildFlags(tags []string) []string {
+	buildFlagTags := getBuildFlagTags(tags)
 	if len(buildFlagTags) == 0 {
 		return nil
 	}
@@ -471,15 +585,33 @@ func getBuildFlagTags(buildTags []string) string {
 
 // isSynthetic checks if an edge is synthetic
 func isSynthetic(edge *callgraph.Edge) bool {
-	return edge.Caller.Func.Pkg == nil || edge.Callee.Func.Pkg == nil || edge.Callee.Func.Synthetic != ""
+	return funcPkg(edge.Caller.Func) == nil || funcPkg(edge.Callee.Func) == nil || isSyntheticFunc(edge.Callee.Func)
+}
+
+// funcPkg returns the package declaring fn. Instantiations of generic
+// functions have no package of their own and use their origin's.
+func funcPkg(fn *ssa.Function) *types.Package {
+	if fn.Pkg == nil {
+		if origin := fn.Origin(); origin != nil && origin != fn {
+			return funcPkg(origin)
+		}
+		return nil
+	}
+	return fn.Pkg.Pkg
+}
+
+// isSyntheticFunc reports whether fn has no source of its own. Instantiations
+// of generic functions are marked synthetic by SSA but keep their syntax.
+func isSyntheticFunc(fn *ssa.Function) bool {
+	return fn.Synthetic != "" && fn.Origin() == nil
 }
 
 // inStd checks if a node is in standard library
 func inStd(node *callgraph.Node) bool {
-    if node == nil || node.Func == nil || node.Func.Pkg == nil || node.Func.Pkg.Pkg == nil {
+    if node == nil || node.Func == nil || funcPkg(node.Func) == nil {
         return false
     }
-    return isStdPkgPath(node.Func.Pkg.Pkg.Path())
+    return isStdPkgPath(funcPkg(node.Func).Path())
 }
 
 // isStdPkgPath checks if a package path is standard library
@@ -530,64 +662,58 @@ func isInternalPkg(path string) bool {
 		   strings.HasPrefix(path, "sync/")
 }
 
-// generateMermaidCallgraph builds a DOT graph using emicklei/dot and returns Mermaid flowchart code
-func generateMermaidCallgraph(a *analysis) (string, MCPCallgraphStats, error) {
+// generateMermaidCallgraph collects the filtered package-level graph and returns it as Mermaid flowchart code and a DOT graph
+func generateMermaidCallgraph(a *analysis) (*graphResult, error) {
     var stats MCPCallgraphStats
+    nodeMap, edgeMap := collectCallgraph(a)
+    return a.newGraphResult(nodeMap, edgeMap, stats), nil
+}
 
-    // Build a DOT graph (directed)
-    g := dot.NewGraph(dot.Directed)
-    g.Attr("label", "callgraph")
+// depthRoots returns the nodes depth is measured from: main functions (or the
+// RTA roots of a library) and init functions, else the nodes without callers
+func (a *analysis) depthRoots() []*callgraph.Node {
+    var roots []*callgraph.Node
+    if mains, err := mainPackages(a.pkgs); err == nil {
+        for _, mp := range mains {
+            if mp != nil {
+                if mf := mp.Func("main"); mf != nil {
+                    if n := a.callgraph.Nodes[mf]; n != nil { roots = append(roots, n) }
+                }
+            }
+        }
+    } else {
+        // libraries analyzed with RTA start from the roots it was given
+        for _, f := range a.roots {
+            if n := a.callgraph.Nodes[f]; n != nil { roots = append(roots, n) }
+        }
+    }
+    if inits, err := initFuncs(a.pkgs); err == nil {
+        for _, f := range inits {
+            if n := a.callgraph.Nodes[f]; n != nil { roots = append(roots, n) }
+        }
+    }
+    // fallback: nodes with no incoming edges
+    if len(roots) == 0 {
+        for _, n := range a.callgraph.Nodes {
+            if n != nil && len(n.In) == 0 { roots = append(roots, n) }
+        }
+    }
+    return roots
+}
 
+// collectCallgraph collects the nodes and edges of the whole callgraph that pass the filters and the depth limit
+func collectCallgraph(a *analysis) (map[string]*MCPCallgraphNode, map[string]*MCPCallgraphEdge) {
     // Helper maps
     nodeMap := make(map[string]*MCPCallgraphNode)
     edgeMap := make(map[string]*MCPCallgraphEdge)
-    dotNodes := make(map[string]dot.Node)
 
     // Get focus package if specified
-    var focusPkg *types.Package
-    if a.opts.focus != "" {
-        if ssaPkg := a.prog.ImportedPackage(a.opts.focus); ssaPkg != nil {
-            focusPkg = ssaPkg.Pkg
-        } else {
-            for _, p := range a.pkgs {
-                if p.Pkg.Name() == a.opts.focus {
-                    if ssaPkg := a.prog.ImportedPackage(p.Pkg.Path()); ssaPkg != nil {
-                        focusPkg = ssaPkg.Pkg
-                        break
-                    }
-                }
-            }
-        }
-    }
-
-    // Delete synthetic nodes
-    a.callgraph.DeleteSyntheticNodes()
+    focusPkg := a.resolveFocusPkg()
 
     // Depth limiting: compute minimal depth from roots (main/init) if maxDep > 0
     depthMap := make(map[*callgraph.Node]int)
     if a.opts.maxDep > 0 {
-        // collect roots: main functions and init functions
-        var roots []*callgraph.Node
-        if mains, err := mainPackages(a.pkgs); err == nil {
-            for _, mp := range mains {
-                if mp != nil {
-                    if mf := mp.Func("main"); mf != nil {
-                        if n := a.callgraph.Nodes[mf]; n != nil { roots = append(roots, n) }
-                    }
-                }
-            }
-        }
-        if inits, err := initFuncs(a.pkgs); err == nil {
-            for _, f := range inits {
-                if n := a.callgraph.Nodes[f]; n != nil { roots = append(roots, n) }
-            }
-        }
-        // fallback: nodes with no incoming edges
-        if len(roots) == 0 {
-            for _, n := range a.callgraph.Nodes {
-                if n != nil && len(n.In) == 0 { roots = append(roots, n) }
-            }
-        }
+        roots := a.depthRoots()
         // BFS
         type qitem struct{ n *callgraph.Node; d int }
         queue := make([]qitem, 0, len(roots))
@@ -614,19 +740,19 @@ func generateMermaidCallgraph(a *analysis) (string, MCPCallgraphStats, error) {
     var isFocused = func(edge *callgraph.Edge) bool {
         caller := edge.Caller
         callee := edge.Callee
-        if focusPkg != nil && (caller.Func.Pkg.Pkg.Path() == focusPkg.Path() || callee.Func.Pkg.Pkg.Path() == focusPkg.Path()) {
+        if focusPkg != nil && (funcPkg(caller.Func).Path() == focusPkg.Path() || funcPkg(callee.Func).Path() == focusPkg.Path()) {
             return true
         }
         fromFocused := false
         for _, e := range caller.In {
-            if !isSynthetic(e) && focusPkg != nil && e.Caller.Func.Pkg.Pkg.Path() == focusPkg.Path() {
+            if !isSynthetic(e) && focusPkg != nil && funcPkg(e.Caller.Func).Path() == focusPkg.Path() {
                 fromFocused = true
                 break
             }
         }
         toFocused := false
         for _, e := range callee.Out {
-            if !isSynthetic(e) && focusPkg != nil && e.Callee.Func.Pkg.Pkg.Path() == focusPkg.Path() {
+            if !isSynthetic(e) && focusPkg != nil && funcPkg(e.Callee.Func).Path() == focusPkg.Path() {
                 toFocused = true
                 break
             }
@@ -635,10 +761,10 @@ func generateMermaidCallgraph(a *analysis) (string, MCPCallgraphStats, error) {
     }
 
     var inIncludes = func(node *callgraph.Node) bool {
-        if node == nil || node.Func == nil || node.Func.Pkg == nil || node.Func.Pkg.Pkg == nil {
+        if node == nil || node.Func == nil || funcPkg(node.Func) == nil {
             return false
         }
-        pkgPath := node.Func.Pkg.Pkg.Path()
+        pkgPath := funcPkg(node.Func).Path()
         for _, p := range a.opts.include {
             if strings.HasPrefix(pkgPath, p) { return true }
         }
@@ -646,10 +772,10 @@ func generateMermaidCallgraph(a *analysis) (string, MCPCallgraphStats, error) {
     }
 
     var inLimits = func(node *callgraph.Node) bool {
-        if node == nil || node.Func == nil || node.Func.Pkg == nil || node.Func.Pkg.Pkg == nil {
+        if node == nil || node.Func == nil || funcPkg(node.Func) == nil {
             return false
         }
-        pkgPath := node.Func.Pkg.Pkg.Path()
+        pkgPath := funcPkg(node.Func).Path()
         for _, p := range a.opts.limit {
             if strings.Contains(pkgPath, p) { return true }
         }
@@ -657,10 +783,10 @@ func generateMermaidCallgraph(a *analysis) (string, MCPCallgraphStats, error) {
     }
 
     var inIgnores = func(node *callgraph.Node) bool {
-        if node == nil || node.Func == nil || node.Func.Pkg == nil || node.Func.Pkg.Pkg == nil {
+        if node == nil || node.Func == nil || funcPkg(node.Func) == nil {
             return false
         }
-        pkgPath := node.Func.Pkg.Pkg.Path()
+        pkgPath := funcPkg(node.Func).Path()
         for _, p := range a.opts.ignore {
             if strings.Contains(pkgPath, p) { return true }
         }
@@ -686,8 +812,8 @@ func generateMermaidCallgraph(a *analysis) (string, MCPCallgraphStats, error) {
             if a.opts.nostd && (inStd(caller) || inStd(callee)) { continue }
             if a.opts.nostd {
                 var callerPath, calleePath string
-                if caller != nil && caller.Func != nil && caller.Func.Pkg != nil && caller.Func.Pkg.Pkg != nil { callerPath = caller.Func.Pkg.Pkg.Path() }
-                if callee != nil && callee.Func != nil && callee.Func.Pkg != nil && callee.Func.Pkg.Pkg != nil { calleePath = callee.Func.Pkg.Pkg.Path() }
+                if caller != nil && caller.Func != nil && funcPkg(caller.Func) != nil { callerPath = funcPkg(caller.Func).Path() }
+                if callee != nil && callee.Func != nil && funcPkg(callee.Func) != nil { calleePath = funcPkg(callee.Func).Path() }
                 if isInternalPkg(callerPath) || isInternalPkg(calleePath) { continue }
             }
             if a.opts.nointer {
@@ -699,6 +825,7 @@ func generateMermaidCallgraph(a *analysis) (string, MCPCallgraphStats, error) {
             if len(a.opts.limit) > 0 && !(inLimits(caller) && inLimits(callee)) { continue }
             if len(a.opts.ignore) > 0 && (inIgnores(caller) || inIgnores(callee)) { continue }
             if focusPkg != nil && !isFocused(e) { continue }
+            if !a.passEdgeKind(callKind(e.Site)) { continue }
 
             callerID := fmt.Sprintf("%s", caller.Func)
             calleeID := fmt.Sprintf("%s", callee.Func)
@@ -712,110 +839,12 @@ func generateMermaidCallgraph(a *analysis) (string, MCPCallgraphStats, error) {
                 nodeMap[calleeID] = createJSONNode(callee, pos)
             }
 
-            // Create DOT nodes
-            dFromID := callerID
-            dToID := calleeID
-            if _, exists := dotNodes[dFromID]; !exists { dotNodes[dFromID] = g.Node(dFromID) }
-            if _, exists := dotNodes[dToID]; !exists { dotNodes[dToID] = g.Node(dToID) }
-            g.Edge(dotNodes[dFromID], dotNodes[dToID])
-
             edgeID := fmt.Sprintf("%s->%s", callerID, calleeID)
-            if _, exists := edgeMap[edgeID]; !exists {
-                pos := a.prog.Fset.Position(e.Pos())
-                edgeMap[edgeID] = &MCPCallgraphEdge{Caller: callerID, Callee: calleeID, File: pos.Filename, Line: pos.Line, Synthetic: isSynthetic(e)}
-            }
-        }
-    }
-
-    // Build Mermaid flowchart text
-    var sb strings.Builder
-    // Direction: Left-to-Right (LR). Could be configurable.
-    sb.WriteString("flowchart LR\n")
-
-    // Numeric compact IDs
-    idIndex := make(map[string]int)
-    nextID := 1
-    resolveID := func(s string) string {
-        if v, ok := idIndex[s]; ok { return fmt.Sprintf("N%d", v) }
-        idIndex[s] = nextID
-        nextID++
-        return fmt.Sprintf("N%d", idIndex[s])
-    }
-
-    // Determine grouping options
-    hasPkg := false
-    hasType := false
-    for _, g := range a.opts.group {
-        if g == "pkg" { hasPkg = true }
-        if g == "type" { hasType = true }
-    }
-
-    // Helper to write a single node line and its file:line comment
-    writeNode := func(id string, n *MCPCallgraphNode) {
-        mid := resolveID(id)
-        label := fmt.Sprintf("%s<br/>%s:%d", n.Func, n.File, n.Line)
-        sb.WriteString(fmt.Sprintf("%s[%q]\n", mid, label))
-    }
-
-    if hasPkg && hasType {
-        // Nested grouping: pkg -> type -> nodes
-        nested := make(map[string]map[string][]string)
-        for id, n := range nodeMap {
-            pkg := n.PackagePath
-            typ := "func"
-            if n.ReceiverType != nil && *n.ReceiverType != "" { typ = *n.ReceiverType }
-            if _, ok := nested[pkg]; !ok { nested[pkg] = make(map[string][]string) }
-            nested[pkg][typ] = append(nested[pkg][typ], id)
-        }
-        for pkg, typeMap := range nested {
-            // Subgraph per package
-            sb.WriteString(fmt.Sprintf("subgraph %q\n", "pkg:"+pkg))
-            for typ, ids := range typeMap {
-                // Subgraph per type within package
-                sb.WriteString(fmt.Sprintf("subgraph %q\n", "type:"+typ))
-                for _, id := range ids { writeNode(id, nodeMap[id]) }
-                sb.WriteString("end\n")
-            }
-            sb.WriteString("end\n")
-        }
-    } else if hasPkg {
-        // Group by package only
-        groups := make(map[string][]string)
-        for id, n := range nodeMap { groups[n.PackagePath] = append(groups[n.PackagePath], id) }
-        for pkg, ids := range groups {
-            sb.WriteString(fmt.Sprintf("subgraph %q\n", "pkg:"+pkg))
-            for _, id := range ids { writeNode(id, nodeMap[id]) }
-            sb.WriteString("end\n")
-        }
-    } else if hasType {
-        // Group by type (receiver) only
-        groups := make(map[string][]string)
-        for id, n := range nodeMap {
-            typ := "func"
-            if n.ReceiverType != nil && *n.ReceiverType != "" { typ = *n.ReceiverType }
-            groups[typ] = append(groups[typ], id)
-        }
-        for typ, ids := range groups {
-            sb.WriteString(fmt.Sprintf("subgraph %q\n", "type:"+typ))
-            for _, id := range ids { writeNode(id, nodeMap[id]) }
-            sb.WriteString("end\n")
+            addCallSite(edgeMap, edgeID, e, a.prog.Fset.Position(e.Pos()), a.opts.algo)
         }
-    } else {
-        // No grouping, declare all nodes at top level
-        for id, n := range nodeMap { writeNode(id, n) }
-    }
-
-    // Declare edges
-    for _, ed := range edgeMap {
-        from := resolveID(ed.Caller)
-        to := resolveID(ed.Callee)
-        sb.WriteString(fmt.Sprintf("%s --> %s\n", from, to))
     }
 
-    stats.NodeCount = len(nodeMap)
-    stats.EdgeCount = len(edgeMap)
-
-    return sb.String(), stats, nil
+    return nodeMap, edgeMap
 }
 
 // sanitizeMermaidID creates a safe identifier for Mermaid nodes
@@ -846,7 +875,7 @@ func sanitizeMermaidID(s string) string {
 
 func createJSONNode(node *callgraph.Node, pos token.Position) *MCPCallgraphNode {
 	fn := node.Func
-	pkg := fn.Pkg.Pkg
+	pkg := funcPkg(fn)
 	
 	var receiverType *string
 	if fn.Signature.Recv() != nil {
@@ -867,56 +896,80 @@ func createJSONNode(node *callgraph.Node, pos token.Position) *MCPCallgraphNode
 	}
 }
 
-// generateMermaidTraversal builds a Mermaid graph starting from a symbol and traversing per direction
-func generateMermaidTraversal(a *analysis, symbol string, direction string) (string, MCPCallgraphStats, error) {
-	var stats MCPCallgraphStats
+func createJSONEdge(edge *callgraph.Edge, pos token.Position, algo CallGraphType) *MCPCallgraphEdge {
+	kind := callKind(edge.Site)
+	return &MCPCallgraphEdge{
+		Caller:    fmt.Sprintf("%s", edge.Caller.Func),
+		Callee:    fmt.Sprintf("%s", edge.Callee.Func),
+		File:      pos.Filename,
+		Line:      pos.Line,
+		Synthetic: isSynthetic(edge),
+		Kind:      kind,
+		Algorithm: string(algo),
+		Count:     1,
+		Sites:     []MCPCallSite{{File: pos.Filename, Line: pos.Line, Column: pos.Column, Kind: kind}},
+	}
+}
 
-	// Resolve focus package (reuse from generateMermaidCallgraph)
-	var focusPkg *types.Package
-	if a.opts.focus != "" {
-		if ssaPkg := a.prog.ImportedPackage(a.opts.focus); ssaPkg != nil {
-			focusPkg = ssaPkg.Pkg
-		} else {
-			for _, p := range a.pkgs {
-				if p.Pkg.Name() == a.opts.focus {
-					if ssaPkg := a.prog.ImportedPackage(p.Pkg.Path()); ssaPkg != nil {
-						focusPkg = ssaPkg.Pkg
-						break
-					}
-				}
-			}
-		}
+// addCallSite records the call site of e on the edge id, creating the edge on
+// its first site. Sites are kept in source order whatever the graph's edge
+// order, and the first one is the edge's primary site.
+func addCallSite(edgeMap map[string]*MCPCallgraphEdge, id string, e *callgraph.Edge, pos token.Position, algo CallGraphType) {
+	ed, exists := edgeMap[id]
+	if !exists {
+		edgeMap[id] = createJSONEdge(e, pos, algo)
+		return
 	}
+	site := MCPCallSite{File: pos.Filename, Line: pos.Line, Column: pos.Column, Kind: callKind(e.Site)}
+	i := sort.Search(len(ed.Sites), func(i int) bool { return !callSiteBefore(ed.Sites[i], site) })
+	if i < len(ed.Sites) && ed.Sites[i] == site {
+		return
+	}
+	ed.Sites = append(ed.Sites, MCPCallSite{})
+	copy(ed.Sites[i+1:], ed.Sites[i:])
+	ed.Sites[i] = site
+	ed.Count = len(ed.Sites)
+	ed.File, ed.Line, ed.Kind = ed.Sites[0].File, ed.Sites[0].Line, ed.Sites[0].Kind
+}
 
-	// Find start node by symbol
-	var start *callgraph.Node
-	for _, n := range a.callgraph.Nodes {
-		fn := n.Func
-		if fn == nil {
-			continue
-		}
-		// Common form: fully-qualified string
-		if symbol == fn.String() || symbol == fn.Name() {
-			start = n
-			break
-		}
-		// Guard against nil package pointers
-		if fn.Pkg != nil && fn.Pkg.Pkg != nil {
-			candPkgName := fmt.Sprintf("%s.%s", fn.Pkg.Pkg.Name(), fn.Name())
-			candPkgPath := fmt.Sprintf("%s.%s", fn.Pkg.Pkg.Path(), fn.Name())
-			if symbol == candPkgName || symbol == candPkgPath {
-				start = n
-				break
+// callSiteBefore orders call sites by file, line, column, then kind
+func callSiteBefore(a, b MCPCallSite) bool {
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	if a.Column != b.Column {
+		return a.Column < b.Column
+	}
+	return a.Kind < b.Kind
+}
+
+// resolveFocusPkg resolves the focus option (package name or import path) to a package
+func (a *analysis) resolveFocusPkg() *types.Package {
+	if a.opts.focus == "" {
+		return nil
+	}
+	if ssaPkg := a.prog.ImportedPackage(a.opts.focus); ssaPkg != nil {
+		return ssaPkg.Pkg
+	}
+	for _, p := range a.pkgs {
+		if p.Pkg.Name() == a.opts.focus {
+			if ssaPkg := a.prog.ImportedPackage(p.Pkg.Path()); ssaPkg != nil {
+				return ssaPkg.Pkg
 			}
 		}
 	}
-	if start == nil {
-		return "", stats, fmt.Errorf("symbol not found: %s", symbol)
-	}
+	return nil
+}
 
+// traversalEdgeFilter returns the edge filter used by symbol traversals
+// (nostd, nointer, limit_prefix, limit_keyword, ignore and focus)
+func (a *analysis) traversalEdgeFilter(focusPkg *types.Package) func(e *callgraph.Edge) bool {
 	// Helper filters (copied from generateMermaidCallgraph)
 	inIncludes := func(node *callgraph.Node) bool {
-		pkgPath := node.Func.Pkg.Pkg.Path()
+		pkgPath := funcPkg(node.Func).Path()
 		for _, p := range a.opts.include {
 			if strings.HasPrefix(pkgPath, p) {
 				return true
@@ -925,7 +978,7 @@ func generateMermaidTraversal(a *analysis, symbol string, direction string) (str
 		return false
 	}
 	inLimits := func(node *callgraph.Node) bool {
-		pkgPath := node.Func.Pkg.Pkg.Path()
+		pkgPath := funcPkg(node.Func).Path()
 		for _, p := range a.opts.limit {
 			if strings.Contains(pkgPath, p) {
 				return true
@@ -934,7 +987,7 @@ func generateMermaidTraversal(a *analysis, symbol string, direction string) (str
 		return false
 	}
 	inIgnores := func(node *callgraph.Node) bool {
-		pkgPath := node.Func.Pkg.Pkg.Path()
+		pkgPath := funcPkg(node.Func).Path()
 		for _, p := range a.opts.ignore {
 			if strings.Contains(pkgPath, p) {
 				return true
@@ -942,7 +995,7 @@ func generateMermaidTraversal(a *analysis, symbol string, direction string) (str
 		}
 		return false
 	}
-	passEdge := func(e *callgraph.Edge) bool {
+	return func(e *callgraph.Edge) bool {
 		if e == nil { return false }
 		if isSynthetic(e) { return false }
 		caller := e.Caller
@@ -951,8 +1004,8 @@ func generateMermaidTraversal(a *analysis, symbol string, direction string) (str
 		if a.opts.nostd && (inStd(caller) || inStd(callee)) { return false }
 		if a.opts.nostd {
 			var cPath, dPath string
-			if caller.Func.Pkg != nil && caller.Func.Pkg.Pkg != nil { cPath = caller.Func.Pkg.Pkg.Path() }
-			if callee.Func != nil && callee.Func.Pkg != nil && callee.Func.Pkg.Pkg != nil { dPath = callee.Func.Pkg.Pkg.Path() }
+			if funcPkg(caller.Func) != nil { cPath = funcPkg(caller.Func).Path() }
+			if callee.Func != nil && funcPkg(callee.Func) != nil { dPath = funcPkg(callee.Func).Path() }
 			if (cPath != "" && isInternalPkg(cPath)) || (dPath != "" && isInternalPkg(dPath)) { return false }
 		}
 		if a.opts.nointer {
@@ -964,198 +1017,109 @@ func generateMermaidTraversal(a *analysis, symbol string, direction string) (str
 		if len(a.opts.ignore) > 0 && (inIgnores(caller) || inIgnores(callee)) { return false }
 		if focusPkg != nil {
 			var cPath, dPath string
-			if caller.Func.Pkg != nil && caller.Func.Pkg.Pkg != nil { cPath = caller.Func.Pkg.Pkg.Path() }
-			if callee.Func.Pkg != nil && callee.Func.Pkg.Pkg != nil { dPath = callee.Func.Pkg.Pkg.Path() }
+			if funcPkg(caller.Func) != nil { cPath = funcPkg(caller.Func).Path() }
+			if funcPkg(callee.Func) != nil { dPath = funcPkg(callee.Func).Path() }
 			if !(cPath == focusPkg.Path() || dPath == focusPkg.Path()) { return false }
 		}
+		if !a.passEdgeKind(callKind(e.Site)) { return false }
 		return true
 	}
+}
+
+// generateMermaidTraversal builds a Mermaid graph starting from the resolved start node and traversing per direction
+func generateMermaidTraversal(a *analysis, start *callgraph.Node, direction string) (*graphResult, error) {
+	var stats MCPCallgraphStats
+
+	focusPkg := a.resolveFocusPkg()
+
+	passEdge := a.traversalEdgeFilter(focusPkg)
 
 	// Traverse according to direction
 	nodeMap := make(map[string]*MCPCallgraphNode)
 	edgeMap := make(map[string]*MCPCallgraphEdge)
-	visited := make(map[*callgraph.Node]bool)
-
-	doDown := func(root *callgraph.Node) {
-		stack := []*callgraph.Node{root}
-		for len(stack) > 0 {
-			n := stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-			if visited[n] {
-				continue
+
+	addEdge := func(e *callgraph.Edge) {
+		caller := e.Caller
+		callee := e.Callee
+		callerID := fmt.Sprintf("%s", caller.Func)
+		calleeID := fmt.Sprintf("%s", callee.Func)
+		if _, ok := nodeMap[callerID]; !ok {
+			pos := a.prog.Fset.Position(caller.Func.Pos())
+			nodeMap[callerID] = createJSONNode(caller, pos)
+		}
+		if _, ok := nodeMap[calleeID]; !ok {
+			pos := a.prog.Fset.Position(callee.Func.Pos())
+			nodeMap[calleeID] = createJSONNode(callee, pos)
+		}
+		edgeID := fmt.Sprintf("%s->%s", callerID, calleeID)
+		addCallSite(edgeMap, edgeID, e, a.prog.Fset.Position(e.Pos()), a.opts.algo)
+	}
+
+	// walk is a depth-bounded BFS (limit 0 means unlimited). Nodes at the
+	// depth limit are not expanded; their hidden neighbors are counted on the
+	// node so the output can show where the traversal was cut off.
+	walk := func(root *callgraph.Node, limit int, down bool) {
+		depth := map[*callgraph.Node]int{root: 0}
+		queue := []*callgraph.Node{root}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			edges := n.Out
+			if !down {
+				edges = n.In
 			}
-			visited[n] = true
-			for _, e := range n.Out {
+			atFrontier := limit > 0 && depth[n] >= limit
+			hidden := make(map[*callgraph.Node]bool)
+			for _, e := range edges {
 				if !passEdge(e) {
 					continue
 				}
-				caller := e.Caller
-				callee := e.Callee
-				callerID := fmt.Sprintf("%s", caller.Func)
-				calleeID := fmt.Sprintf("%s", callee.Func)
-				if _, ok := nodeMap[callerID]; !ok {
-					pos := a.prog.Fset.Position(caller.Func.Pos())
-					nodeMap[callerID] = createJSONNode(caller, pos)
-				}
-				if _, ok := nodeMap[calleeID]; !ok {
-					pos := a.prog.Fset.Position(callee.Func.Pos())
-					nodeMap[calleeID] = createJSONNode(callee, pos)
-				}
-				edgeID := fmt.Sprintf("%s->%s", callerID, calleeID)
-				if _, exists := edgeMap[edgeID]; !exists {
-					pos := a.prog.Fset.Position(e.Pos())
-					edgeMap[edgeID] = &MCPCallgraphEdge{Caller: callerID, Callee: calleeID, File: pos.Filename, Line: pos.Line, Synthetic: isSynthetic(e)}
+				next := e.Callee
+				if !down {
+					next = e.Caller
 				}
-				stack = append(stack, callee)
-			}
-		}
-	}
-	doUp := func(root *callgraph.Node) {
-		stack := []*callgraph.Node{root}
-		for len(stack) > 0 {
-			n := stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-			if visited[n] {
-				continue
-			}
-			visited[n] = true
-			for _, e := range n.In {
-				if !passEdge(e) {
+				_, seen := depth[next]
+				if atFrontier {
+					// Edges back into the shown graph are kept, the rest is hidden
+					if seen {
+						addEdge(e)
+					} else {
+						hidden[next] = true
+					}
 					continue
 				}
-				caller := e.Caller
-				callee := e.Callee
-				callerID := fmt.Sprintf("%s", caller.Func)
-				calleeID := fmt.Sprintf("%s", callee.Func)
-				if _, ok := nodeMap[callerID]; !ok {
-					pos := a.prog.Fset.Position(caller.Func.Pos())
-					nodeMap[callerID] = createJSONNode(caller, pos)
+				addEdge(e)
+				if !seen {
+					depth[next] = depth[n] + 1
+					queue = append(queue, next)
 				}
-				if _, ok := nodeMap[calleeID]; !ok {
-					pos := a.prog.Fset.Position(callee.Func.Pos())
-					nodeMap[calleeID] = createJSONNode(callee, pos)
+			}
+			if len(hidden) > 0 {
+				id := fmt.Sprintf("%s", n.Func)
+				if _, ok := nodeMap[id]; !ok {
+					nodeMap[id] = createJSONNode(n, a.prog.Fset.Position(n.Func.Pos()))
 				}
-				edgeID := fmt.Sprintf("%s->%s", callerID, calleeID)
-				if _, exists := edgeMap[edgeID]; !exists {
-					pos := a.prog.Fset.Position(e.Pos())
-					edgeMap[edgeID] = &MCPCallgraphEdge{Caller: callerID, Callee: calleeID, File: pos.Filename, Line: pos.Line, Synthetic: isSynthetic(e)}
+				if down {
+					nodeMap[id].MoreCallees = len(hidden)
+				} else {
+					nodeMap[id].MoreCallers = len(hidden)
 				}
-				stack = append(stack, caller)
 			}
 		}
 	}
 
 	switch direction {
 	case "downstream":
-		doDown(start)
+		walk(start, a.opts.maxDepDown, true)
 	case "upstream":
-		doUp(start)
+		walk(start, a.opts.maxDepUp, false)
 	case "both":
-		doDown(start)
-		// reset visited for upstream union
-		visited = make(map[*callgraph.Node]bool)
-		doUp(start)
+		walk(start, a.opts.maxDepDown, true)
+		walk(start, a.opts.maxDepUp, false)
 	default:
-		doDown(start)
+		walk(start, a.opts.maxDepDown, true)
 	}
 
-	// Build Mermaid flowchart text (same grouping logic)
-	var sb strings.Builder
-	sb.WriteString("flowchart LR\n")
-
-	// Numeric compact IDs (traversal)
-	idIndex := make(map[string]int)
-	nextID := 1
-	resolveID := func(s string) string {
-	    if v, ok := idIndex[s]; ok { return fmt.Sprintf("N%d", v) }
-	    idIndex[s] = nextID
-	    nextID++
-	    return fmt.Sprintf("N%d", idIndex[s])
-	}
-
-	// Determine grouping options
-	hasPkg := false
-	hasType := false
-	for _, g := range a.opts.group {
-		if g == "pkg" {
-			hasPkg = true
-		}
-		if g == "type" {
-			hasType = true
-		}
-	}
-	writeNode := func(id string, n *MCPCallgraphNode) {
-		mid := resolveID(id)
-		label := fmt.Sprintf("%s<br/>%s:%d", n.Func, n.File, n.Line)
-		sb.WriteString(fmt.Sprintf("%s[%q]\n", mid, label))
-	}
-	if hasPkg && hasType {
-		nested := make(map[string]map[string][]string)
-		for id, n := range nodeMap {
-			pkg := n.PackagePath
-			typ := "func"
-			if n.ReceiverType != nil && *n.ReceiverType != "" {
-				typ = *n.ReceiverType
-			}
-			if _, ok := nested[pkg]; !ok {
-				nested[pkg] = make(map[string][]string)
-			}
-			nested[pkg][typ] = append(nested[pkg][typ], id)
-		}
-		for pkg, typeMap := range nested {
-			// Subgraph per package
-			sb.WriteString(fmt.Sprintf("subgraph %q\n", "pkg:"+pkg))
-			for typ, ids := range typeMap {
-				// Subgraph per type within package
-				sb.WriteString(fmt.Sprintf("subgraph %q\n", "type:"+typ))
-				for _, id := range ids {
-					writeNode(id, nodeMap[id])
-				}
-				sb.WriteString("end\n")
-			}
-			sb.WriteString("end\n")
-		}
-	} else if hasPkg {
-		groups := make(map[string][]string)
-		for id, n := range nodeMap {
-			groups[n.PackagePath] = append(groups[n.PackagePath], id)
-		}
-		for pkg, ids := range groups {
-			sb.WriteString(fmt.Sprintf("subgraph %q\n", "pkg:"+pkg))
-			for _, id := range ids {
-				writeNode(id, nodeMap[id])
-			}
-			sb.WriteString("end\n")
-		}
-	} else if hasType {
-		groups := make(map[string][]string)
-		for id, n := range nodeMap {
-			typ := "func"
-			if n.ReceiverType != nil && *n.ReceiverType != "" {
-				typ = *n.ReceiverType
-			}
-			groups[typ] = append(groups[typ], id)
-		}
-		for typ, ids := range groups {
-			sb.WriteString(fmt.Sprintf("subgraph %q\n", "type:"+typ))
-			for _, id := range ids {
-				writeNode(id, nodeMap[id])
-			}
-			sb.WriteString("end\n")
-		}
-	} else {
-		for id, n := range nodeMap {
-			writeNode(id, n)
-		}
-	}
-	for _, ed := range edgeMap {
-		from := resolveID(ed.Caller)
-		to := resolveID(ed.Callee)
-		sb.WriteString(fmt.Sprintf("%s --> %s\n", from, to))
-	}
 
-	// Stats
-	stats.NodeCount = len(nodeMap)
-	stats.EdgeCount = len(edgeMap)
-	return sb.String(), stats, nil
+	return a.newGraphResult(nodeMap, edgeMap, stats), nil
 }
\ No newline at end of file
@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/callgraph"
+)
+
+// Supported values of the callPath mode parameter
+const (
+	CallPathModeShortest  = "shortest"
+	CallPathModeKShortest = "k_shortest"
+	CallPathModeAll       = "all"
+)
+
+// Defaults for the callPath bounds
+const (
+	defaultCallPathK         = 3
+	defaultCallPathMaxPaths  = 100
+	defaultCallPathMaxLength = 10
+)
+
+// MCPCallPathRequest represents the input parameters for the callPath tool via MCP.
+// Analysis and filter parameters are shared with callHierarchy.
+type MCPCallPathRequest struct {
+	MCPCallgraphRequest
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Mode      string `json:"mode,omitempty"`
+	K         int    `json:"k,omitempty"`
+	MaxLength int    `json:"max_length,omitempty"`
+}
+
+// MCPCallPathResponse represents the output of the callPath tool via MCP
+type MCPCallPathResponse struct {
+	Algorithm  string        `json:"algorithm"`
+	From       string        `json:"from"`
+	To         string        `json:"to"`
+	Mode       string        `json:"mode"`
+	Paths      []MCPCallPath `json:"paths"`
+	Truncated  bool          `json:"truncated"`
+	DurationMs int           `json:"durationMs"`
+	Warnings   []string      `json:"warnings,omitempty"`
+}
+
+// MCPCallPath is one call chain; each hop is an edge with its call site
+type MCPCallPath struct {
+	Length int                `json:"length"`
+	Hops   []MCPCallgraphEdge `json:"hops"`
+}
+
+// HandleCallPathRequest processes the MCP callPath request
+func HandleCallPathRequest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	start := time.Now()
+
+	var req MCPCallPathRequest
+	if err := decodeArguments(request, &req); err != nil {
+		return errorResult("%v", err), nil
+	}
+
+	// Validate required parameters
+	if len(req.ModuleArgs) == 0 {
+		return errorResult("Error: moduleArgs is required"), nil
+	}
+	if req.From == "" || req.To == "" {
+		return errorResult("Error: from and to are required"), nil
+	}
+	if req.Mode == "" {
+		req.Mode = CallPathModeShortest
+	}
+	switch req.Mode {
+	case CallPathModeShortest, CallPathModeKShortest, CallPathModeAll:
+	default:
+		return errorResult("Error: invalid mode %q (expected shortest, k_shortest or all)", req.Mode), nil
+	}
+	if req.OutputFormat != "" && req.OutputFormat != "text" && req.OutputFormat != OutputFormatJSON {
+		return errorResult("Error: invalid output_format %q (expected text or json)", req.OutputFormat), nil
+	}
+	if err := validateEdgeKinds(req.EdgeKinds); err != nil {
+		return errorResult("Error: %v", err), nil
+	}
+	req.applyDefaults(request)
+
+	ctx = withProgress(ctx, request)
+	ctx, cancel := withRequestTimeout(ctx, req.MCPCallgraphRequest)
+	defer cancel()
+
+	a, err := newRequestAnalysis(ctx, req.MCPCallgraphRequest)
+	if err != nil {
+		return errorResult("%v", err), nil
+	}
+
+	from, err := a.findSymbolNode(req.From)
+	if err != nil {
+		return errorResult("Error finding call paths: %v", err), nil
+	}
+	to, err := a.findSymbolNode(req.To)
+	if err != nil {
+		return errorResult("Error finding call paths: %v", err), nil
+	}
+	if from == to {
+		return errorResult("Error finding call paths: from and to resolve to the same function %s", from.Func), nil
+	}
+
+	pf := newPathFinder(to, a.traversalEdgeFilter(a.resolveFocusPkg()))
+	paths, truncated, err := pf.find(ctx, from, req.Mode, req.K, req.MaxLength)
+	if err != nil {
+		return errorResult("%v", err), nil
+	}
+	if err := checkContext(ctx, phaseFilter); err != nil {
+		return errorResult("%v", err), nil
+	}
+	progressf(ctx, stepRender, "found %d call path(s); rendering", len(paths))
+
+	resp := &MCPCallPathResponse{
+		Algorithm: req.Algo,
+		From:      fmt.Sprintf("%s", from.Func),
+		To:        fmt.Sprintf("%s", to.Func),
+		Mode:      req.Mode,
+		Paths:     make([]MCPCallPath, 0, len(paths)),
+		Truncated: truncated,
+		Warnings:  a.warnings,
+	}
+	for _, p := range paths {
+		cp := MCPCallPath{Length: len(p)}
+		for _, e := range p {
+			cp.Hops = append(cp.Hops, *createJSONEdge(e, a.prog.Fset.Position(e.Pos()), a.opts.algo))
+		}
+		resp.Paths = append(resp.Paths, cp)
+	}
+	resp.DurationMs = int(time.Since(start).Milliseconds())
+
+	if req.OutputFormat == OutputFormatJSON {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return errorResult("Error rendering output: %v", err), nil
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(string(data))}}, nil
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(formatCallPaths(resp))}}, nil
+}
+
+// pathFinder enumerates simple call paths to a target over the filtered callgraph
+type pathFinder struct {
+	target *callgraph.Node
+	pass   func(e *callgraph.Edge) bool
+	dist   map[*callgraph.Node]int // number of calls needed to reach target
+}
+
+// newPathFinder computes distances to target with a reverse BFS over passing edges
+func newPathFinder(target *callgraph.Node, pass func(e *callgraph.Edge) bool) *pathFinder {
+	pf := &pathFinder{target: target, pass: pass, dist: map[*callgraph.Node]int{target: 0}}
+	queue := []*callgraph.Node{target}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, e := range n.In {
+			if !pass(e) {
+				continue
+			}
+			if _, ok := pf.dist[e.Caller]; !ok {
+				pf.dist[e.Caller] = pf.dist[n] + 1
+				queue = append(queue, e.Caller)
+			}
+		}
+	}
+	return pf
+}
+
+// successors returns one passing edge per distinct callee that can reach the target,
+// ordered by callee name so results are stable
+func (pf *pathFinder) successors(n *callgraph.Node) []*callgraph.Edge {
+	var out []*callgraph.Edge
+	seen := make(map[*callgraph.Node]bool)
+	for _, e := range n.Out {
+		if seen[e.Callee] || !pf.pass(e) {
+			continue
+		}
+		if _, ok := pf.dist[e.Callee]; !ok {
+			continue
+		}
+		seen[e.Callee] = true
+		out = append(out, e)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Callee.Func.String() < out[j].Callee.Func.String()
+	})
+	return out
+}
+
+// enumerate collects up to limit simple paths from start whose length is at
+// most bound (exactly bound when exact is set). It reports whether the limit cut the search short,
+// and stops with a cancelledError once ctx is done.
+func (pf *pathFinder) enumerate(ctx context.Context, start *callgraph.Node, bound int, exact bool, limit int) ([][]*callgraph.Edge, bool, error) {
+	var paths [][]*callgraph.Edge
+	truncated := false
+	onPath := map[*callgraph.Node]bool{start: true}
+	var path []*callgraph.Edge
+	var err error
+	visits := 0
+	var walk func(n *callgraph.Node)
+	walk = func(n *callgraph.Node) {
+		if truncated || err != nil {
+			return
+		}
+		if visits++; visits%contextCheckInterval == 0 {
+			if err = checkContext(ctx, phasePaths); err != nil {
+				return
+			}
+		}
+		if n == pf.target {
+			if !exact || len(path) == bound {
+				if len(paths) >= limit {
+					truncated = true
+					return
+				}
+				paths = append(paths, append([]*callgraph.Edge(nil), path...))
+			}
+			return
+		}
+		for _, e := range pf.successors(n) {
+			c := e.Callee
+			if onPath[c] || len(path)+1+pf.dist[c] > bound {
+				continue
+			}
+			onPath[c] = true
+			path = append(path, e)
+			walk(c)
+			path = path[:len(path)-1]
+			onPath[c] = false
+		}
+	}
+	walk(start)
+	if err != nil {
+		return nil, false, err
+	}
+	return paths, truncated, nil
+}
+
+// find returns the paths requested by mode, shortest first
+func (pf *pathFinder) find(ctx context.Context, start *callgraph.Node, mode string, k int, maxLength int) ([][]*callgraph.Edge, bool, error) {
+	shortest, ok := pf.dist[start]
+	if !ok {
+		return nil, false, nil
+	}
+	switch mode {
+	case CallPathModeKShortest:
+		if k <= 0 {
+			k = defaultCallPathK
+		}
+		if maxLength <= 0 {
+			maxLength = defaultCallPathMaxLength
+		}
+		var paths [][]*callgraph.Edge
+		truncated := false
+		l := shortest
+		for ; l <= maxLength && len(paths) < k; l++ {
+			found, more, err := pf.enumerate(ctx, start, l, true, k-len(paths))
+			if err != nil {
+				return nil, false, err
+			}
+			paths = append(paths, found...)
+			truncated = truncated || more
+		}
+		if len(paths) == k && l <= maxLength {
+			// Stopped before max_length: longer paths may exist
+			truncated = true
+		}
+		return paths, truncated, nil
+	case CallPathModeAll:
+		if k <= 0 {
+			k = defaultCallPathMaxPaths
+		}
+		if maxLength <= 0 {
+			maxLength = defaultCallPathMaxLength
+		}
+		paths, truncated, err := pf.enumerate(ctx, start, maxLength, false, k)
+		sort.SliceStable(paths, func(i, j int) bool { return len(paths[i]) < len(paths[j]) })
+		return paths, truncated, err
+	default:
+		if maxLength > 0 && shortest > maxLength {
+			return nil, false, nil
+		}
+		return pf.enumerate(ctx, start, shortest, true, 1)
+	}
+}
+
+// formatCallPaths renders the paths as readable text, one hop per line
+func formatCallPaths(resp *MCPCallPathResponse) string {
+	var sb strings.Builder
+	for _, w := range resp.Warnings {
+		sb.WriteString("warning: " + w + "\n")
+	}
+	if len(resp.Paths) == 0 {
+		sb.WriteString(fmt.Sprintf("No call path found from %s to %s (algo: %s, mode: %s)\n", resp.From, resp.To, resp.Algorithm, resp.Mode))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("Found %d call path(s) from %s to %s (algo: %s, mode: %s)\n", len(resp.Paths), resp.From, resp.To, resp.Algorithm, resp.Mode))
+	for i, p := range resp.Paths {
+		sb.WriteString(fmt.Sprintf("\nPath %d (%d calls):\n", i+1, p.Length))
+		for j, h := range p.Hops {
+			sb.WriteString(fmt.Sprintf("  %d. %s -> %s  [%s:%d]\n", j+1, h.Caller, h.Callee, h.File, h.Line))
+		}
+	}
+	if resp.Truncated {
+		switch resp.Mode {
+		case CallPathModeShortest:
+			sb.WriteString("\n(other paths of the same length exist; use mode k_shortest or all to list them)\n")
+		case CallPathModeKShortest:
+			sb.WriteString("\n(more paths may exist within max_length; increase k to list them)\n")
+		default:
+			sb.WriteString("\n(more paths exist; increase k or lower max_length to refine)\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxCompletionValues is the MCP limit on values in a completion response
+const maxCompletionValues = 100
+
+// maxCompletionDirs bounds the directories walked to complete moduleArgs
+const maxCompletionDirs = 10000
+
+// directions are the values of the direction argument
+var directions = []string{"downstream", "upstream", "both"}
+
+// CompletionProvider completes the arguments of the prompts and of the graph
+// resource template: symbol from the function and method names of the cached
+// analysis, limit_prefix and sink from its import paths, and moduleArgs from
+// the package directories of dir. Completion never loads packages: symbols
+// and import paths are offered once a tool call has analyzed dir.
+type CompletionProvider struct{}
+
+// CompletePromptArgument completes a prompt argument; dir and moduleArgs
+// are taken from the arguments the client already filled in
+func (CompletionProvider) CompletePromptArgument(ctx context.Context, promptName string, argument mcp.CompleteArgument, cctx mcp.CompleteContext) (*mcp.Completion, error) {
+	dir := cctx.Arguments["dir"]
+	moduleArgs := splitModuleArgs(cctx.Arguments["moduleArgs"])
+	values, err := completeArgument(ctx, argument, dir, moduleArgs)
+	if err != nil {
+		return nil, err
+	}
+	return newCompletion(values), nil
+}
+
+// CompleteResourceArgument completes an argument of the graph resource
+// template; symbols and import paths come from the graph named by the hash
+// argument, or the latest graph of the workspace
+func (CompletionProvider) CompleteResourceArgument(ctx context.Context, uri string, argument mcp.CompleteArgument, cctx mcp.CompleteContext) (*mcp.Completion, error) {
+	workspace, hash := cctx.Arguments["workspace"], cctx.Arguments["hash"]
+
+	graphRegistry.mu.Lock()
+	var workspaces, hashes []string
+	var graph *graphEntry
+	seen := make(map[string]bool)
+	for _, h := range graphRegistry.order {
+		e := graphRegistry.entries[h]
+		if !seen[e.workspace] {
+			seen[e.workspace] = true
+			workspaces = append(workspaces, e.workspace)
+		}
+		if workspace != "" && e.workspace != workspace {
+			continue
+		}
+		hashes = append(hashes, h)
+		if hash == "" || h == hash {
+			graph = e // the latest matching graph wins
+		}
+	}
+	graphRegistry.mu.Unlock()
+
+	switch argument.Name {
+	case "workspace":
+		return newCompletion(rankStrings(argument.Value, workspaces)), nil
+	case "hash":
+		return newCompletion(rankStrings(argument.Value, hashes)), nil
+	case "view":
+		return newCompletion(rankStrings(argument.Value, graphViews)), nil
+	}
+	if graph == nil {
+		return newCompletion(nil), nil
+	}
+	values, err := completeArgument(ctx, argument, graph.req.Dir, graph.req.ModuleArgs)
+	if err != nil {
+		return nil, err
+	}
+	return newCompletion(values), nil
+}
+
+// completeArgument returns the ranked values for argument, from the cached
+// analysis of dir and moduleArgs when the argument needs the callgraph
+func completeArgument(ctx context.Context, argument mcp.CompleteArgument, dir string, moduleArgs []string) ([]string, error) {
+	switch argument.Name {
+	case "direction":
+		return rankStrings(argument.Value, directions), nil
+	case "moduleArgs":
+		return completeModuleArgs(dir, argument.Value)
+	case "symbol", "limit_prefix", "sink":
+	default:
+		return nil, nil
+	}
+	if dir == "" {
+		// Nothing to analyze until the client fills in dir
+		return nil, nil
+	}
+	a, err := completionAnalysis(dir, moduleArgs)
+	if err != nil || a == nil {
+		return nil, err
+	}
+	if argument.Name == "symbol" {
+		var values []string
+		if strings.TrimSpace(argument.Value) == "" {
+			for _, n := range a.symbolCandidates() {
+				values = append(values, n.Func.String())
+			}
+			return values, nil
+		}
+		for _, m := range a.searchSymbols(argument.Value) {
+			values = append(values, m.node.Func.String())
+		}
+		return values, nil
+	}
+	return rankStrings(argument.Value, a.importPaths(argument.Name == "limit_prefix")), nil
+}
+
+// completionAnalysis returns the latest fresh cached analysis of dir and
+// moduleArgs with any algorithm, or nil when there is none; completion
+// requests are too frequent to afford loading packages
+func completionAnalysis(dir string, moduleArgs []string) (*analysis, error) {
+	if len(moduleArgs) == 0 {
+		moduleArgs = []string{"./..."}
+	}
+	if err := checkWorkspace(dir, moduleArgs); err != nil {
+		return nil, err
+	}
+	if e := analysisCache.lookup(dir, moduleArgs); e != nil {
+		return &analysis{
+			opts:      &renderOpts{nostd: true},
+			prog:      e.prog,
+			pkgs:      e.pkgs,
+			mainPkg:   e.mainPkg,
+			callgraph: e.graph,
+			roots:     e.roots,
+		}, nil
+	}
+	return nil, nil
+}
+
+// importPaths returns the import paths of the analyzed program, sorted; with
+// parents, the paths of their parent directories are included too, as
+// prefixes of several packages (standard library parents excepted)
+func (a *analysis) importPaths(parents bool) []string {
+	set := make(map[string]bool)
+	for _, p := range a.prog.AllPackages() {
+		path := p.Pkg.Path()
+		set[path] = true
+		if !parents || isStdPkgPath(path) {
+			continue
+		}
+		for i := strings.LastIndex(path, "/"); i > 0; i = strings.LastIndex(path[:i], "/") {
+			set[path[:i]] = true
+		}
+	}
+	paths := make([]string, 0, len(set))
+	for p := range set {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// completeModuleArgs completes the last of comma or space separated package
+// patterns with the package directories under dir, as ./dir and ./dir/...
+func completeModuleArgs(dir, value string) ([]string, error) {
+	if dir == "" {
+		return rankStrings(value, []string{"./..."}), nil
+	}
+	if err := checkWorkspace(dir, nil); err != nil {
+		return nil, err
+	}
+	head, last := "", value
+	if i := strings.LastIndexAny(value, ", "); i >= 0 {
+		head, last = value[:i+1], value[i+1:]
+	}
+
+	set := map[string]bool{"./...": true}
+	walked := 0
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			if walked++; walked > maxCompletionDirs {
+				return filepath.SkipAll
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".go") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filepath.Dir(path))
+		if err != nil || rel == "." {
+			set["."] = true
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		set["./"+rel] = true
+		// Every enclosing directory matches it with a wildcard
+		for p := rel; p != "."; p = filepath.ToSlash(filepath.Dir(p)) {
+			set["./"+p+"/..."] = true
+		}
+		return nil
+	})
+
+	patterns := make([]string, 0, len(set))
+	for p := range set {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+	values := rankStrings(last, patterns)
+	for i, v := range values {
+		values[i] = head + v
+	}
+	return values, nil
+}
+
+// splitModuleArgs splits comma or space separated package patterns
+func splitModuleArgs(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// rankStrings returns the candidates matching query, case-insensitively, best
+// first: exact, prefix, substring, then in-order subsequence matches. An
+// empty query matches every candidate, in their order.
+func rankStrings(query string, candidates []string) []string {
+	type match struct {
+		value string
+		score int
+	}
+	if query == "" {
+		return append([]string(nil), candidates...)
+	}
+	q := strings.ToLower(query)
+	var matches []match
+	for _, c := range candidates {
+		s := strings.ToLower(c)
+		var score int
+		switch {
+		case s == q:
+			score = 900
+		case strings.HasPrefix(s, q):
+			score = 800 - clampScore(len(s)-len(q))
+		case strings.Contains(s, q):
+			score = 700 - clampScore(strings.Index(s, q))
+		default:
+			score = subsequenceScore(q, s)
+		}
+		if score > 0 {
+			matches = append(matches, match{c, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	values := make([]string, len(matches))
+	for i, m := range matches {
+		values[i] = m.value
+	}
+	return values
+}
+
+// newCompletion caps values at the MCP limit and reports how many there were
+func newCompletion(values []string) *mcp.Completion {
+	c := &mcp.Completion{Values: values, Total: len(values)}
+	if c.Values == nil {
+		c.Values = []string{}
+	}
+	if len(values) > maxCompletionValues {
+		c.Values = values[:maxCompletionValues]
+		c.HasMore = true
+	}
+	return c
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/callgraph"
+)
+
+// MCPFindCyclesRequest represents the input parameters for the findCycles tool via MCP.
+// Analysis and filter parameters are shared with callHierarchy.
+type MCPFindCyclesRequest struct {
+	MCPCallgraphRequest
+}
+
+// MCPFindCyclesResponse represents the output of the findCycles tool via MCP
+type MCPFindCyclesResponse struct {
+	Algorithm   string     `json:"algorithm"`
+	Granularity string     `json:"granularity"`
+	Cycles      []MCPCycle `json:"cycles"`
+	DurationMs  int        `json:"durationMs"`
+	Warnings    []string   `json:"warnings,omitempty"`
+}
+
+// MCPCycle is a strongly connected component of the callgraph: every member
+// can reach every other one. Path is one shortest cycle through its first
+// member, each hop with its call sites; an aggregated hop (granularity type
+// or pkg) lists the sites of every call it merges.
+type MCPCycle struct {
+	Members []string           `json:"members"`
+	Path    []MCPCallgraphEdge `json:"path"`
+}
+
+// HandleFindCyclesRequest processes the MCP findCycles request
+func HandleFindCyclesRequest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	start := time.Now()
+
+	var req MCPFindCyclesRequest
+	if err := decodeArguments(request, &req); err != nil {
+		return errorResult("%v", err), nil
+	}
+
+	// Validate required parameters
+	if len(req.ModuleArgs) == 0 {
+		return errorResult("Error: moduleArgs is required"), nil
+	}
+	switch req.OutputFormat {
+	case "", "text", OutputFormatJSON, OutputFormatMermaid, OutputFormatDot, OutputFormatSVG:
+	default:
+		return errorResult("Error: invalid output_format %q (expected text, json, mermaid, dot or svg)", req.OutputFormat), nil
+	}
+	if err := validateEdgeKinds(req.EdgeKinds); err != nil {
+		return errorResult("Error: %v", err), nil
+	}
+	if !isValidGranularity(req.Granularity) {
+		return errorResult("Error: invalid granularity %q (expected one of %s)", req.Granularity, strings.Join(granularities, ", ")), nil
+	}
+	if req.Granularity == "" {
+		req.Granularity = GranularityFunc
+	}
+	req.applyDefaults(request)
+	// Recursion mostly goes through unexported helpers: keep them unless asked not to
+	if args, ok := request.Params.Arguments.(map[string]interface{}); ok {
+		if _, exists := args["nointer"]; !exists {
+			req.NoInter = false
+		}
+	}
+
+	ctx = withProgress(ctx, request)
+	ctx, cancel := withRequestTimeout(ctx, req.MCPCallgraphRequest)
+	defer cancel()
+
+	a, err := newRequestAnalysis(ctx, req.MCPCallgraphRequest)
+	if err != nil {
+		return errorResult("%v", err), nil
+	}
+
+	funcNodes, funcEdges := a.filteredGraph(a.traversalEdgeFilter(a.resolveFocusPkg()))
+	nodeMap, edgeMap := collapseGraph(funcNodes, funcEdges, req.Granularity)
+	sccs := stronglyConnected(nodeMap, edgeMap)
+	if err := checkContext(ctx, phaseFilter); err != nil {
+		return errorResult("%v", err), nil
+	}
+	progressf(ctx, stepRender, "found %d call cycle(s); rendering", len(sccs))
+
+	resp := &MCPFindCyclesResponse{
+		Algorithm:   req.Algo,
+		Granularity: req.Granularity,
+		Cycles:      make([]MCPCycle, 0, len(sccs)),
+		Warnings:    a.warnings,
+	}
+	cycleNodes := make(map[string]*MCPCallgraphNode)
+	cycleEdges := make(map[string]*MCPCallgraphEdge)
+	for _, members := range sccs {
+		path := shortestCycle(members, edgeMap)
+		c := MCPCycle{Members: members}
+		onPath := make(map[*MCPCallgraphEdge]bool, len(path))
+		for _, ed := range path {
+			hop := *ed
+			if req.Granularity != GranularityFunc {
+				hop.Sites = aggregatedSites(&hop, funcNodes, funcEdges, req.Granularity)
+			}
+			c.Path = append(c.Path, hop)
+			onPath[ed] = true
+		}
+		resp.Cycles = append(resp.Cycles, c)
+
+		inSCC := make(map[string]bool, len(members))
+		for _, id := range members {
+			inSCC[id] = true
+			cycleNodes[id] = nodeMap[id]
+		}
+		for id, ed := range edgeMap {
+			if inSCC[ed.Caller] && inSCC[ed.Callee] {
+				marked := *ed
+				marked.Cycle = onPath[ed]
+				cycleEdges[id] = &marked
+			}
+		}
+	}
+	resp.DurationMs = int(time.Since(start).Milliseconds())
+
+	switch req.OutputFormat {
+	case OutputFormatJSON:
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return errorResult("Error rendering output: %v", err), nil
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(string(data))}}, nil
+	case OutputFormatMermaid, OutputFormatDot, OutputFormatSVG:
+		result := a.renderGraphAs(cycleNodes, cycleEdges, req.Granularity)
+		content, err := renderOutput(req.OutputFormat, result, &MCPCallgraphResponse{Warnings: resp.Warnings})
+		if err != nil {
+			return errorResult("Error rendering output: %v", err), nil
+		}
+		return &mcp.CallToolResult{Content: content}, nil
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(formatCycles(resp))}}, nil
+}
+
+// filteredGraph collects the function-level nodes and edges passing pass,
+// without depth limit
+func (a *analysis) filteredGraph(pass func(e *callgraph.Edge) bool) (map[string]*MCPCallgraphNode, map[string]*MCPCallgraphEdge) {
+	nodeMap := make(map[string]*MCPCallgraphNode)
+	edgeMap := make(map[string]*MCPCallgraphEdge)
+	for _, n := range a.callgraph.Nodes {
+		for _, e := range n.Out {
+			if !pass(e) {
+				continue
+			}
+			callerID := e.Caller.Func.String()
+			calleeID := e.Callee.Func.String()
+			if _, ok := nodeMap[callerID]; !ok {
+				nodeMap[callerID] = createJSONNode(e.Caller, a.prog.Fset.Position(e.Caller.Func.Pos()))
+			}
+			if _, ok := nodeMap[calleeID]; !ok {
+				nodeMap[calleeID] = createJSONNode(e.Callee, a.prog.Fset.Position(e.Callee.Func.Pos()))
+			}
+			addCallSite(edgeMap, fmt.Sprintf("%s->%s", callerID, calleeID), e, a.prog.Fset.Position(e.Pos()), a.opts.algo)
+		}
+	}
+	return nodeMap, edgeMap
+}
+
+// aggregatedSites returns the call sites of the function-level edges behind
+// the aggregated edge ed, in source order
+func aggregatedSites(ed *MCPCallgraphEdge, funcNodes map[string]*MCPCallgraphNode, funcEdges map[string]*MCPCallgraphEdge, granularity string) []MCPCallSite {
+	var sites []MCPCallSite
+	for _, fe := range funcEdges {
+		caller, callee := funcNodes[fe.Caller], funcNodes[fe.Callee]
+		if caller == nil || callee == nil || collapsedKey(caller, granularity) != ed.Caller || collapsedKey(callee, granularity) != ed.Callee {
+			continue
+		}
+		sites = append(sites, fe.Sites...)
+	}
+	sort.SliceStable(sites, func(i, j int) bool { return callSiteBefore(sites[i], sites[j]) })
+	return sites
+}
+
+// stronglyConnected returns the non-trivial strongly connected components of
+// the graph (several members, or one calling itself), each with sorted
+// members; larger components come first
+func stronglyConnected(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge) [][]string {
+	out := make(map[string][]string)
+	selfLoop := make(map[string]bool)
+	for _, ed := range sortedEdges(edgeMap) {
+		if ed.Caller == ed.Callee {
+			selfLoop[ed.Caller] = true
+		}
+		out[ed.Caller] = append(out[ed.Caller], ed.Callee)
+	}
+
+	// Tarjan's algorithm
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var sccs [][]string
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = len(index)
+		lowlink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+		for _, next := range out[id] {
+			if _, seen := index[next]; !seen {
+				visit(next)
+				lowlink[id] = min(lowlink[id], lowlink[next])
+			} else if onStack[next] {
+				lowlink[id] = min(lowlink[id], index[next])
+			}
+		}
+		if lowlink[id] != index[id] {
+			return
+		}
+		var scc []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			scc = append(scc, top)
+			if top == id {
+				break
+			}
+		}
+		if len(scc) > 1 || selfLoop[id] {
+			sort.Strings(scc)
+			sccs = append(sccs, scc)
+		}
+	}
+	for _, id := range sortedNodeIDs(nodeMap) {
+		if _, seen := index[id]; !seen {
+			visit(id)
+		}
+	}
+
+	sort.Slice(sccs, func(i, j int) bool {
+		if len(sccs[i]) != len(sccs[j]) {
+			return len(sccs[i]) > len(sccs[j])
+		}
+		return sccs[i][0] < sccs[j][0]
+	})
+	return sccs
+}
+
+// shortestCycle returns the edges of a shortest cycle through the first
+// member of a strongly connected component, found by a BFS within it
+func shortestCycle(members []string, edgeMap map[string]*MCPCallgraphEdge) []*MCPCallgraphEdge {
+	inSCC := make(map[string]bool, len(members))
+	for _, id := range members {
+		inSCC[id] = true
+	}
+	out := make(map[string][]*MCPCallgraphEdge)
+	for _, ed := range sortedEdges(edgeMap) {
+		if inSCC[ed.Caller] && inSCC[ed.Callee] {
+			out[ed.Caller] = append(out[ed.Caller], ed)
+		}
+	}
+
+	start := members[0]
+	via := map[string]*MCPCallgraphEdge{start: nil}
+	queue := []string{start}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, ed := range out[id] {
+			if ed.Callee == start {
+				path := []*MCPCallgraphEdge{ed}
+				for at := id; via[at] != nil; at = via[at].Caller {
+					path = append(path, via[at])
+				}
+				for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+			if _, seen := via[ed.Callee]; !seen {
+				via[ed.Callee] = ed
+				queue = append(queue, ed.Callee)
+			}
+		}
+	}
+	return nil
+}
+
+// formatCycles renders the cycles as readable text, one hop per line
+func formatCycles(resp *MCPFindCyclesResponse) string {
+	var sb strings.Builder
+	for _, w := range resp.Warnings {
+		sb.WriteString("warning: " + w + "\n")
+	}
+	if len(resp.Cycles) == 0 {
+		sb.WriteString(fmt.Sprintf("No call cycle found (algo: %s, granularity: %s)\n", resp.Algorithm, resp.Granularity))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("Found %d call cycle(s) (algo: %s, granularity: %s)\n", len(resp.Cycles), resp.Algorithm, resp.Granularity))
+	for i, c := range resp.Cycles {
+		what := "recursive"
+		if len(c.Members) > 1 {
+			what = fmt.Sprintf("%d members", len(c.Members))
+		}
+		sb.WriteString(fmt.Sprintf("\nCycle %d (%s): %s\n", i+1, what, strings.Join(c.Members, ", ")))
+		for j, h := range c.Path {
+			sb.WriteString(fmt.Sprintf("  %d. %s -> %s  [%s:%d]\n", j+1, h.Caller, h.Callee, h.File, h.Line))
+		}
+	}
+	return sb.String()
+}
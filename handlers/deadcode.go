@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/ssa"
+)
+
+// MCPDeadCodeRequest represents the input parameters for the deadCode tool via MCP.
+// Analysis parameters are shared with callHierarchy; the algorithm is always RTA.
+type MCPDeadCodeRequest struct {
+	MCPCallgraphRequest
+}
+
+// MCPDeadCodeResponse represents the output of the deadCode tool via MCP
+type MCPDeadCodeResponse struct {
+	Algorithm  string           `json:"algorithm"`
+	RtaRoots   string           `json:"rta_roots"`
+	Roots      int              `json:"roots"`
+	Packages   []MCPDeadPackage `json:"packages"`
+	Total      int              `json:"total"`
+	DurationMs int              `json:"durationMs"`
+	Warnings   []string         `json:"warnings,omitempty"`
+}
+
+// MCPDeadPackage lists the unreachable functions of one package, in source order
+type MCPDeadPackage struct {
+	Path      string            `json:"path"`
+	Functions []MCPDeadFunction `json:"functions"`
+}
+
+// MCPDeadFunction is a function or method no root can reach
+type MCPDeadFunction struct {
+	Name         string  `json:"name"`
+	Func         string  `json:"func"`
+	ReceiverType *string `json:"receiverType,omitempty"`
+	File         string  `json:"file"`
+	Line         int     `json:"line"`
+	Exported     bool    `json:"exported"`
+}
+
+// HandleDeadCodeRequest processes the MCP deadCode request
+func HandleDeadCodeRequest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	start := time.Now()
+
+	var req MCPDeadCodeRequest
+	if err := decodeArguments(request, &req); err != nil {
+		return errorResult("%v", err), nil
+	}
+
+	// Validate required parameters
+	if len(req.ModuleArgs) == 0 {
+		return errorResult("Error: moduleArgs is required"), nil
+	}
+	if req.OutputFormat != "" && req.OutputFormat != "text" && req.OutputFormat != OutputFormatJSON {
+		return errorResult("Error: invalid output_format %q (expected text or json)", req.OutputFormat), nil
+	}
+	if req.Algo != "" && req.Algo != string(CallGraphTypeRta) {
+		return errorResult("Error: deadCode only supports algo %q", CallGraphTypeRta), nil
+	}
+	req.applyDefaults(request)
+
+	ctx = withProgress(ctx, request)
+	ctx, cancel := withRequestTimeout(ctx, req.MCPCallgraphRequest)
+	defer cancel()
+
+	a, err := newRequestAnalysis(ctx, req.MCPCallgraphRequest)
+	if err != nil {
+		return errorResult("%v", err), nil
+	}
+
+	dead := a.deadFuncs()
+	if err := checkContext(ctx, phaseFilter); err != nil {
+		return errorResult("%v", err), nil
+	}
+	progressf(ctx, stepRender, "%d unreachable function(s); rendering", len(dead))
+
+	rtaRoots := req.RtaRoots
+	if rtaRoots == "" {
+		rtaRoots = RootsAuto
+	}
+	resp := &MCPDeadCodeResponse{
+		Algorithm: req.Algo,
+		RtaRoots:  rtaRoots,
+		Roots:     len(a.roots),
+		Packages:  []MCPDeadPackage{},
+		Total:     len(dead),
+		Warnings:  a.warnings,
+	}
+	for _, fn := range dead {
+		pkgPath := funcPkg(fn).Path()
+		if n := len(resp.Packages); n == 0 || resp.Packages[n-1].Path != pkgPath {
+			resp.Packages = append(resp.Packages, MCPDeadPackage{Path: pkgPath})
+		}
+		last := &resp.Packages[len(resp.Packages)-1]
+		last.Functions = append(last.Functions, a.newDeadFunction(fn))
+	}
+	resp.DurationMs = int(time.Since(start).Milliseconds())
+
+	if req.OutputFormat == OutputFormatJSON {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return errorResult("Error rendering output: %v", err), nil
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(string(data))}}, nil
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(formatDeadCode(resp))}}, nil
+}
+
+// deadFuncs returns the functions and methods declared in the initial
+// packages that the RTA callgraph does not reach, ordered by package and
+// position. Roots count as reached even without calls. A generic function is reachable when one of its instantiations
+// is; package initializers are roots. With tests, a function compiled into
+// both a package and its test variant is dead only if neither reaches it.
+func (a *analysis) deadFuncs() []*ssa.Function {
+	live := make(map[*ssa.Function]bool, len(a.callgraph.Nodes))
+	for _, fn := range a.roots {
+		live[fn] = true
+	}
+	for fn := range a.callgraph.Nodes {
+		if fn == nil {
+			continue
+		}
+		live[fn] = true
+		if origin := fn.Origin(); origin != nil {
+			live[origin] = true
+		}
+	}
+
+	type declKey struct {
+		name string
+		pos  string
+	}
+	liveDecl := make(map[declKey]bool)
+	var candidates []*ssa.Function
+	var keys []declKey
+	for _, fn := range memberFuncs(a.prog, a.pkgs) {
+		if fn.Name() == "init" || isSyntheticFunc(fn) || funcPkg(fn) == nil {
+			continue
+		}
+		pkgPath := funcPkg(fn).Path()
+		if strings.HasSuffix(pkgPath, ".test") {
+			// Generated test main package
+			continue
+		}
+		if len(a.opts.include) > 0 && !hasAnyPrefix(pkgPath, a.opts.include) {
+			continue
+		}
+		if containsAny(pkgPath, a.opts.ignore) {
+			continue
+		}
+		key := declKey{name: fn.String(), pos: a.prog.Fset.Position(fn.Pos()).String()}
+		if live[fn] {
+			liveDecl[key] = true
+			continue
+		}
+		candidates = append(candidates, fn)
+		keys = append(keys, key)
+	}
+
+	var dead []*ssa.Function
+	seen := make(map[declKey]bool)
+	for i, fn := range candidates {
+		if liveDecl[keys[i]] || seen[keys[i]] {
+			continue
+		}
+		seen[keys[i]] = true
+		dead = append(dead, fn)
+	}
+	sort.Slice(dead, func(i, j int) bool {
+		pi, pj := funcPkg(dead[i]).Path(), funcPkg(dead[j]).Path()
+		if pi != pj {
+			return pi < pj
+		}
+		posI, posJ := a.prog.Fset.Position(dead[i].Pos()), a.prog.Fset.Position(dead[j].Pos())
+		if posI.Filename != posJ.Filename {
+			return posI.Filename < posJ.Filename
+		}
+		if posI.Line != posJ.Line {
+			return posI.Line < posJ.Line
+		}
+		return dead[i].String() < dead[j].String()
+	})
+	return dead
+}
+
+// newDeadFunction describes fn for deadCode output
+func (a *analysis) newDeadFunction(fn *ssa.Function) MCPDeadFunction {
+	s := a.newSymbol(fn, 0)
+	return MCPDeadFunction{
+		Name:         s.Name,
+		Func:         fn.RelString(funcPkg(fn)),
+		ReceiverType: s.ReceiverType,
+		File:         s.File,
+		Line:         s.Line,
+		Exported:     s.Exported,
+	}
+}
+
+// formatDeadCode renders the unreachable functions as readable text, grouped by package
+func formatDeadCode(resp *MCPDeadCodeResponse) string {
+	var sb strings.Builder
+	for _, w := range resp.Warnings {
+		sb.WriteString("warning: " + w + "\n")
+	}
+	if resp.Total == 0 {
+		sb.WriteString(fmt.Sprintf("No unreachable function (algo: %s, rta_roots: %s, %d roots)\n", resp.Algorithm, resp.RtaRoots, resp.Roots))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("Found %d unreachable function(s) in %d package(s) (algo: %s, rta_roots: %s, %d roots)\n",
+		resp.Total, len(resp.Packages), resp.Algorithm, resp.RtaRoots, resp.Roots))
+	for _, p := range resp.Packages {
+		sb.WriteString("\n" + p.Path + "\n")
+		for _, f := range p.Functions {
+			visibility := "unexported"
+			if f.Exported {
+				visibility = "exported"
+			}
+			sb.WriteString(fmt.Sprintf("  %s:%d  %s  (%s)\n", f.File, f.Line, f.Func, visibility))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emicklei/dot"
+)
+
+// groupFlags reports whether nodes are grouped by package and/or receiver type
+func groupFlags(group []string) (hasPkg bool, hasType bool) {
+	for _, g := range group {
+		if g == "pkg" {
+			hasPkg = true
+		}
+		if g == "type" {
+			hasType = true
+		}
+	}
+	return
+}
+
+// nodeTypeGroup returns the receiver type used for "type" grouping ("func" for plain functions)
+func nodeTypeGroup(n *MCPCallgraphNode) string {
+	if n.ReceiverType != nil && *n.ReceiverType != "" {
+		return *n.ReceiverType
+	}
+	return "func"
+}
+
+// buildDotGraph builds a Graphviz graph from the collected nodes and edges,
+// using clusters for the same pkg/type grouping as the Mermaid output and
+// the same call count labels.
+func buildDotGraph(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge, group []string, counts bool) *dot.Graph {
+	g := dot.NewGraph(dot.Directed)
+	g.Attr("label", "callgraph")
+	g.Attr("rankdir", "LR")
+
+	hasPkg, hasType := groupFlags(group)
+	dotNodes := make(map[string]dot.Node)
+	// Nodes are created in sorted order: the DOT writer numbers them by creation
+	for _, id := range sortedNodeIDs(nodeMap) {
+		n := nodeMap[id]
+		parent := g
+		if hasPkg {
+			parent = parent.Subgraph("pkg:"+n.PackagePath, dot.ClusterOption{})
+		}
+		if hasType {
+			parent = parent.Subgraph("type:"+nodeTypeGroup(n), dot.ClusterOption{})
+		}
+		dn := parent.Node(id).Label(n.Func).Box()
+		dn.Attr("tooltip", fmt.Sprintf("%s\n%s", id, nodeLocation(n)))
+		if n.MoreCallees > 0 || n.MoreCallers > 0 {
+			// Cut off by the depth limit
+			dn.Attr("style", "dashed")
+			dn.Attr("color", "#d9480f")
+			dn.Attr("xlabel", frontierLabel(n))
+		}
+		dotNodes[id] = dn
+	}
+
+	for _, ed := range sortedEdges(edgeMap) {
+		from, okFrom := dotNodes[ed.Caller]
+		to, okTo := dotNodes[ed.Callee]
+		if !okFrom || !okTo {
+			continue
+		}
+		de := g.Edge(from, to)
+		if ed.File != "" {
+			// One line per call site
+			sites := []string{fmt.Sprintf("%s:%d", ed.File, ed.Line)}
+			if len(ed.Sites) > 0 {
+				sites = sites[:0]
+				for _, site := range ed.Sites {
+					sites = append(sites, fmt.Sprintf("%s:%d:%d", site.File, site.Line, site.Column))
+				}
+			}
+			de.Attr("tooltip", strings.Join(sites, "\n"))
+		}
+		var labels []string
+		switch {
+		case ed.Kind == EdgeKindGo:
+			de.Attr("penwidth", "2.5")
+		case ed.Kind == EdgeKindDefer:
+			labels = append(labels, "defer")
+		case dynamicKind(ed.Kind):
+			de.Attr("style", "dashed")
+		}
+		if ed.Cycle {
+			// Same red as the Mermaid linkStyle of findCycles
+			de.Attr("color", "#d62728")
+			de.Attr("penwidth", "3")
+		}
+		if label := edgeCountLabel(ed, counts); label != "" {
+			labels = append(labels, label)
+		}
+		if len(labels) > 0 {
+			de.Attr("label", strings.Join(labels, ", "))
+		}
+	}
+	return g
+}
+
+// frontierLabel describes what the depth limit hid behind a node
+func frontierLabel(n *MCPCallgraphNode) string {
+	var parts []string
+	if n.MoreCallees > 0 {
+		parts = append(parts, fmt.Sprintf("+%d more callees", n.MoreCallees))
+	}
+	if n.MoreCallers > 0 {
+		parts = append(parts, fmt.Sprintf("+%d more callers", n.MoreCallers))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Call kinds of an edge, from its call instruction
+const (
+	EdgeKindStatic    = "static"    // direct call to a statically known function
+	EdgeKindInterface = "interface" // interface method dispatch
+	EdgeKindClosure   = "closure"   // call through a function value (closure, func variable or method value)
+	EdgeKindGo        = "go"        // goroutine spawn
+	EdgeKindDefer     = "defer"     // deferred call
+)
+
+// EdgeKindMixed is the kind of an aggregated edge merging calls of several
+// kinds; it is not a filter value
+const EdgeKindMixed = "mixed"
+
+var edgeKinds = []string{EdgeKindStatic, EdgeKindInterface, EdgeKindClosure, EdgeKindGo, EdgeKindDefer}
+
+// callKind classifies a call site; go and defer take precedence over how
+// the callee is resolved. Edges without a site (synthetic) count as static.
+func callKind(site ssa.CallInstruction) string {
+	switch site.(type) {
+	case nil:
+		return EdgeKindStatic
+	case *ssa.Go:
+		return EdgeKindGo
+	case *ssa.Defer:
+		return EdgeKindDefer
+	}
+	common := site.Common()
+	switch {
+	case common.IsInvoke():
+		return EdgeKindInterface
+	case common.StaticCallee() == nil:
+		return EdgeKindClosure
+	}
+	return EdgeKindStatic
+}
+
+// mergeEdgeKind combines kind ("" before the first edge) with the kinds of
+// the call sites of ed, giving EdgeKindMixed when they differ
+func mergeEdgeKind(kind string, ed *MCPCallgraphEdge) string {
+	kinds := []string{ed.Kind}
+	for _, site := range ed.Sites {
+		kinds = append(kinds, site.Kind)
+	}
+	for _, k := range kinds {
+		switch {
+		case k == "" || k == kind:
+		case kind == "":
+			kind = k
+		default:
+			return EdgeKindMixed
+		}
+	}
+	return kind
+}
+
+// dynamicKind reports whether the callee of kind is only known at run time
+func dynamicKind(kind string) bool {
+	return kind == EdgeKindInterface || kind == EdgeKindClosure
+}
+
+// validateEdgeKinds checks the edge_kinds parameter
+func validateEdgeKinds(kinds []string) error {
+	for _, k := range kinds {
+		valid := false
+		for _, known := range edgeKinds {
+			if k == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid edge_kinds value %q (expected one of %s)", k, strings.Join(edgeKinds, ", "))
+		}
+	}
+	return nil
+}
+
+// passEdgeKind reports whether kind is selected by the edge_kinds filter (empty: all)
+func (a *analysis) passEdgeKind(kind string) bool {
+	if len(a.opts.edgeKinds) == 0 {
+		return true
+	}
+	for _, k := range a.opts.edgeKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
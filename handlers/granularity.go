@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported values of the granularity parameter
+const (
+	GranularityFunc = "func" // one node per function (default)
+	GranularityType = "type" // one node per receiver type; plain functions per package
+	GranularityPkg  = "pkg"  // one node per package
+)
+
+var granularities = []string{GranularityFunc, GranularityType, GranularityPkg}
+
+// isValidGranularity reports whether g is empty (default) or a known granularity
+func isValidGranularity(g string) bool {
+	if g == "" {
+		return true
+	}
+	for _, known := range granularities {
+		if g == known {
+			return true
+		}
+	}
+	return false
+}
+
+// collapsedKey returns the ID of the aggregated node n belongs to
+func collapsedKey(n *MCPCallgraphNode, granularity string) string {
+	switch {
+	case granularity == GranularityType && n.ReceiverType != nil:
+		return strings.TrimPrefix(*n.ReceiverType, "*")
+	case granularity == GranularityType || granularity == GranularityPkg:
+		return n.PackagePath
+	}
+	return n.ID
+}
+
+// collapseGraph aggregates the function-level graph by package or receiver
+// type
+func collapseGraph(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge, granularity string) (map[string]*MCPCallgraphNode, map[string]*MCPCallgraphEdge) {
+	return collapseNodes(nodeMap, edgeMap, func(n *MCPCallgraphNode) string { return collapsedKey(n, granularity) })
+}
+
+// collapseNodes merges the nodes sharing a key into one node, named after a
+// package or a receiver type; nodes keyed by their own ID are kept as is. An
+// aggregated edge counts every call between its two ends, keeps the first
+// call site as its primary site and their kind when they all agree (else
+// mixed); calls within an aggregated node are dropped.
+func collapseNodes(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge, keyOf func(*MCPCallgraphNode) string) (map[string]*MCPCallgraphNode, map[string]*MCPCallgraphEdge) {
+	groupOf := make(map[string]string, len(nodeMap))
+	nodes := make(map[string]*MCPCallgraphNode)
+	for _, id := range sortedNodeIDs(nodeMap) {
+		n := nodeMap[id]
+		key := keyOf(n)
+		groupOf[id] = key
+		if key == id {
+			nodes[id] = n
+			continue
+		}
+		g, ok := nodes[key]
+		if !ok {
+			g = &MCPCallgraphNode{
+				ID:          key,
+				Func:        n.PackagePath,
+				PackagePath: n.PackagePath,
+				PackageName: n.PackageName,
+				IsStd:       n.IsStd,
+			}
+			if key != n.PackagePath {
+				// Receiver type, named like the Mermaid type groups
+				g.Func = n.PackageName + strings.TrimPrefix(key, n.PackagePath)
+				receiverType := key
+				g.ReceiverType = &receiverType
+			}
+			nodes[key] = g
+		}
+		g.Members++
+		g.Exported = g.Exported || n.Exported
+	}
+
+	edges := make(map[string]*MCPCallgraphEdge)
+	for id, ed := range edgeMap {
+		from, to := groupOf[ed.Caller], groupOf[ed.Callee]
+		if from == "" || to == "" {
+			continue
+		}
+		if from == ed.Caller && to == ed.Callee {
+			edges[id] = ed
+			continue
+		}
+		if from == to {
+			continue
+		}
+		id := fmt.Sprintf("%s->%s", from, to)
+		agg, ok := edges[id]
+		if !ok {
+			agg = &MCPCallgraphEdge{Caller: from, Callee: to, File: ed.File, Line: ed.Line, Algorithm: ed.Algorithm}
+			edges[id] = agg
+		} else if callSiteBefore(MCPCallSite{File: ed.File, Line: ed.Line}, MCPCallSite{File: agg.File, Line: agg.Line}) {
+			agg.File, agg.Line = ed.File, ed.Line
+		}
+		agg.Kind = mergeEdgeKind(agg.Kind, ed)
+		count := ed.Count
+		if count == 0 {
+			count = 1
+		}
+		agg.Count += count
+	}
+	return nodes, edges
+}
+
+// newGraphResult renders the collected graph, aggregated per the granularity
+// option and degraded to fit the output budget, if any
+func (a *analysis) newGraphResult(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge, stats MCPCallgraphStats) *graphResult {
+	keyOf := func(n *MCPCallgraphNode) string { return collapsedKey(n, a.opts.granularity) }
+	result := a.renderGraph(collapseNodes(nodeMap, edgeMap, keyOf))
+	if !a.fitsBudget(result) {
+		result = a.fitBudget(nodeMap, edgeMap, keyOf)
+	}
+	result.stats.DurationMs = stats.DurationMs
+	return result
+}
+
+// renderGraph renders a graph aggregated per the granularity option
+func (a *analysis) renderGraph(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge) *graphResult {
+	return a.renderGraphAs(nodeMap, edgeMap, a.opts.granularity)
+}
+
+// renderGraphAs renders a graph in every output format. Aggregated type nodes
+// are only grouped by package, being type groups themselves, and packages
+// are not grouped at all when every node is one.
+func (a *analysis) renderGraphAs(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge, granularity string) *graphResult {
+	group := a.opts.group
+	switch granularity {
+	case GranularityType:
+		group = nil
+		if hasPkg, _ := groupFlags(a.opts.group); hasPkg {
+			group = []string{"pkg"}
+		}
+	case GranularityPkg:
+		group = nil
+	}
+	return &graphResult{
+		mermaid: renderMermaid(nodeMap, edgeMap, group, a.opts.callCounts),
+		dot:     buildDotGraph(nodeMap, edgeMap, group, a.opts.callCounts),
+		data:    newGraphData(nodeMap, edgeMap),
+		stats:   MCPCallgraphStats{NodeCount: len(nodeMap), EdgeCount: len(edgeMap)},
+		group:   group,
+	}
+}
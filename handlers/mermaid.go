@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// renderMermaid renders the collected graph as a Mermaid flowchart grouped by
+// pkg and/or type. Groups, nodes and edges are written in sorted order and the
+// compact N1, N2... IDs are assigned in that order, so the same request always
+// produces the same text. Edges are labeled per edgeCountLabel; cycle edges
+// are drawn in red.
+func renderMermaid(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge, group []string, counts bool) string {
+	var sb strings.Builder
+	// Direction: Left-to-Right (LR). Could be configurable.
+	sb.WriteString("flowchart LR\n")
+
+	// Numeric compact IDs
+	idIndex := make(map[string]int)
+	resolveID := func(s string) string {
+		if _, ok := idIndex[s]; !ok {
+			idIndex[s] = len(idIndex) + 1
+		}
+		return fmt.Sprintf("N%d", idIndex[s])
+	}
+
+	// Helper to write a single node line with its file:line
+	writeNode := func(id string) {
+		n := nodeMap[id]
+		label := fmt.Sprintf("%s<br/>%s", n.Func, nodeLocation(n))
+		sb.WriteString(fmt.Sprintf("%s[%q]\n", resolveID(id), label))
+	}
+	writeGroup := func(label string, ids []string, body func([]string)) {
+		sb.WriteString(fmt.Sprintf("subgraph %q\n", label))
+		body(ids)
+		sb.WriteString("end\n")
+	}
+	writeNodes := func(ids []string) {
+		for _, id := range ids {
+			writeNode(id)
+		}
+	}
+	byType := func(ids []string) {
+		groups := groupNodeIDs(nodeMap, ids, nodeTypeGroup)
+		for _, typ := range sortedKeys(groups) {
+			writeGroup("type:"+typ, groups[typ], writeNodes)
+		}
+	}
+
+	ids := sortedNodeIDs(nodeMap)
+	hasPkg, hasType := groupFlags(group)
+	switch {
+	case hasPkg:
+		// Subgraph per package, nested per type when grouping by both
+		inner := writeNodes
+		if hasType {
+			inner = byType
+		}
+		groups := groupNodeIDs(nodeMap, ids, func(n *MCPCallgraphNode) string { return n.PackagePath })
+		for _, pkg := range sortedKeys(groups) {
+			writeGroup("pkg:"+pkg, groups[pkg], inner)
+		}
+	case hasType:
+		byType(ids)
+	default:
+		writeNodes(ids)
+	}
+
+	// Declare edges in the order of their endpoints' compact IDs
+	edges := make([]*MCPCallgraphEdge, 0, len(edgeMap))
+	for _, ed := range edgeMap {
+		edges = append(edges, ed)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		ci, cj := idIndex[edges[i].Caller], idIndex[edges[j].Caller]
+		if ci != cj {
+			return ci < cj
+		}
+		return idIndex[edges[i].Callee] < idIndex[edges[j].Callee]
+	})
+	var cycle []string
+	for i, ed := range edges {
+		sb.WriteString(fmt.Sprintf("%s %s %s\n", resolveID(ed.Caller), mermaidArrow(ed.Kind, edgeCountLabel(ed, counts)), resolveID(ed.Callee)))
+		if ed.Cycle {
+			cycle = append(cycle, fmt.Sprint(i))
+		}
+	}
+	if len(cycle) > 0 {
+		// Edges are styled by their declaration index
+		sb.WriteString(fmt.Sprintf("linkStyle %s stroke:#d62728,stroke-width:3px\n", strings.Join(cycle, ",")))
+	}
+
+	writeFrontier(&sb, nodeMap, ids, resolveID)
+	return sb.String()
+}
+
+// edgeCountLabel returns the call count label of ed: always for aggregated
+// edges, which have no individual sites, and with counts for edges with
+// several call sites
+func edgeCountLabel(ed *MCPCallgraphEdge, counts bool) string {
+	if (len(ed.Sites) == 0 && ed.Count > 0) || (counts && ed.Count > 1) {
+		return callCountLabel(ed.Count)
+	}
+	return ""
+}
+
+// callCountLabel describes a number of calls
+func callCountLabel(n int) string {
+	if n == 1 {
+		return "1 call"
+	}
+	return fmt.Sprintf("%d calls", n)
+}
+
+// nodeLocation returns the file:line of a function node, or the number of
+// functions of an aggregated node
+func nodeLocation(n *MCPCallgraphNode) string {
+	switch {
+	case n.Members == 1:
+		return "1 func"
+	case n.Members > 1:
+		return fmt.Sprintf("%d funcs", n.Members)
+	}
+	return fmt.Sprintf("%s:%d", n.File, n.Line)
+}
+
+// mermaidArrow draws dynamic calls dotted, goroutine spawns thick and
+// deferred calls with a label, followed by the optional label
+func mermaidArrow(kind, label string) string {
+	if kind == EdgeKindDefer {
+		label = strings.TrimSuffix("defer, "+label, ", ")
+	}
+	switch {
+	case kind == EdgeKindGo:
+		if label != "" {
+			return "== " + label + " ==>"
+		}
+		return "==>"
+	case dynamicKind(kind):
+		if label != "" {
+			return "-. " + label + " .->"
+		}
+		return "-.->"
+	}
+	if label != "" {
+		return "-- " + label + " -->"
+	}
+	return "-->"
+}
+
+// writeFrontier marks nodes cut off by the depth limit and adds a stub per
+// node telling how many callees/callers were not expanded
+func writeFrontier(sb *strings.Builder, nodeMap map[string]*MCPCallgraphNode, ids []string, resolveID func(string) string) {
+	var frontier []string
+	for _, id := range ids {
+		n := nodeMap[id]
+		if n.MoreCallees == 0 && n.MoreCallers == 0 {
+			continue
+		}
+		mid := resolveID(id)
+		frontier = append(frontier, mid)
+		if n.MoreCallees > 0 {
+			sb.WriteString(fmt.Sprintf("%s -.-> %s_more_callees([\"+%d more callees\"])\n", mid, mid, n.MoreCallees))
+		}
+		if n.MoreCallers > 0 {
+			sb.WriteString(fmt.Sprintf("%s_more_callers([\"+%d more callers\"]) -.-> %s\n", mid, n.MoreCallers, mid))
+		}
+	}
+	if len(frontier) == 0 {
+		return
+	}
+	sb.WriteString("classDef frontier stroke-dasharray:5 5,stroke:#d9480f\n")
+	sb.WriteString(fmt.Sprintf("class %s frontier\n", strings.Join(frontier, ",")))
+}
+
+// groupNodeIDs splits ids by key, keeping their order within each group
+func groupNodeIDs(nodeMap map[string]*MCPCallgraphNode, ids []string, key func(*MCPCallgraphNode) string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, id := range ids {
+		k := key(nodeMap[id])
+		groups[k] = append(groups[k], id)
+	}
+	return groups
+}
+
+// sortedKeys returns the keys of m in ascending order
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Supported values of the callgraphMetrics sort_by parameter
+const (
+	MetricFanIn       = "fan_in"
+	MetricFanOut      = "fan_out"
+	MetricCallers     = "callers"
+	MetricCallees     = "callees"
+	MetricBetweenness = "betweenness"
+)
+
+var metricKeys = []string{MetricFanIn, MetricFanOut, MetricCallers, MetricCallees, MetricBetweenness}
+
+// OutputFormatMarkdown renders the callgraphMetrics tables without JSON
+const OutputFormatMarkdown = "markdown"
+
+// defaultMetricsTopN bounds the functions reported by callgraphMetrics
+const defaultMetricsTopN = 20
+
+// MCPMetricsRequest represents the input parameters for the callgraphMetrics tool via MCP.
+// Analysis and filter parameters are shared with callHierarchy.
+type MCPMetricsRequest struct {
+	MCPCallgraphRequest
+	TopN   int    `json:"top_n,omitempty"`
+	SortBy string `json:"sort_by,omitempty"`
+}
+
+// MCPMetricsResponse represents the output of the callgraphMetrics tool via MCP
+type MCPMetricsResponse struct {
+	Algorithm  string               `json:"algorithm"`
+	SortBy     string               `json:"sortBy"`
+	Functions  []MCPFunctionMetrics `json:"functions"` // top_n functions by sortBy
+	Total      int                  `json:"total"`     // functions in the filtered graph
+	Packages   []MCPPackageMetrics  `json:"packages"`  // every package, most coupled first
+	DurationMs int                  `json:"durationMs"`
+	Warnings   []string             `json:"warnings,omitempty"`
+}
+
+// MCPFunctionMetrics describes the position of a function in the filtered callgraph
+type MCPFunctionMetrics struct {
+	Func        string  `json:"func"`
+	PackagePath string  `json:"packagePath"`
+	File        string  `json:"file"`
+	Line        int     `json:"line"`
+	FanIn       int     `json:"fanIn"`       // distinct direct callers
+	FanOut      int     `json:"fanOut"`      // distinct direct callees
+	Callers     int     `json:"callers"`     // distinct transitive callers
+	Callees     int     `json:"callees"`     // distinct transitive callees
+	Depth       *int    `json:"depth"`       // calls from the nearest root; null when no root reaches it
+	Betweenness float64 `json:"betweenness"` // share of shortest paths between other functions going through it (0-1)
+}
+
+// MCPPackageMetrics describes the coupling of a package with the other packages of the graph
+type MCPPackageMetrics struct {
+	Path        string  `json:"path"`
+	Functions   int     `json:"functions"`
+	Afferent    int     `json:"afferent"`    // Ca: packages calling into this one
+	Efferent    int     `json:"efferent"`    // Ce: packages this one calls
+	Instability float64 `json:"instability"` // Ce / (Ca + Ce); 0 when isolated
+}
+
+// HandleMetricsRequest processes the MCP callgraphMetrics request
+func HandleMetricsRequest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	start := time.Now()
+
+	var req MCPMetricsRequest
+	if err := decodeArguments(request, &req); err != nil {
+		return errorResult("%v", err), nil
+	}
+
+	// Validate required parameters
+	if len(req.ModuleArgs) == 0 {
+		return errorResult("Error: moduleArgs is required"), nil
+	}
+	if req.OutputFormat == "" {
+		req.OutputFormat = OutputFormatBoth
+	}
+	switch req.OutputFormat {
+	case OutputFormatMarkdown, OutputFormatJSON, OutputFormatBoth:
+	default:
+		return errorResult("Error: invalid output_format %q (expected markdown, json or both)", req.OutputFormat), nil
+	}
+	if req.SortBy == "" {
+		req.SortBy = MetricBetweenness
+	}
+	if !isMetricKey(req.SortBy) {
+		return errorResult("Error: invalid sort_by %q (expected one of %s)", req.SortBy, strings.Join(metricKeys, ", ")), nil
+	}
+	if err := validateEdgeKinds(req.EdgeKinds); err != nil {
+		return errorResult("Error: %v", err), nil
+	}
+	if req.TopN <= 0 {
+		req.TopN = defaultMetricsTopN
+	}
+	req.applyDefaults(request)
+	// Metrics cover the whole graph, hubs are often unexported: no depth limit
+	// and unexported functions kept unless asked otherwise
+	if args, ok := request.Params.Arguments.(map[string]interface{}); ok {
+		if _, exists := args["max_dep"]; !exists {
+			req.MaxDep = 0
+		}
+		if _, exists := args["nointer"]; !exists {
+			req.NoInter = false
+		}
+	}
+
+	ctx = withProgress(ctx, request)
+	ctx, cancel := withRequestTimeout(ctx, req.MCPCallgraphRequest)
+	defer cancel()
+
+	a, err := newRequestAnalysis(ctx, req.MCPCallgraphRequest)
+	if err != nil {
+		return errorResult("%v", err), nil
+	}
+
+	nodeMap, edgeMap := collectCallgraph(a)
+	functions, err := a.functionMetrics(ctx, nodeMap, edgeMap)
+	if err != nil {
+		return errorResult("%v", err), nil
+	}
+	progressf(ctx, stepRender, "computed metrics of %d function(s); rendering", len(functions))
+
+	sortFunctionMetrics(functions, req.SortBy)
+	resp := &MCPMetricsResponse{
+		Algorithm: req.Algo,
+		SortBy:    req.SortBy,
+		Functions: functions,
+		Total:     len(functions),
+		Packages:  packageMetrics(nodeMap, edgeMap),
+		Warnings:  a.warnings,
+	}
+	if len(resp.Functions) > req.TopN {
+		resp.Functions = resp.Functions[:req.TopN]
+	}
+	resp.DurationMs = int(time.Since(start).Milliseconds())
+
+	var content []mcp.Content
+	if req.OutputFormat != OutputFormatJSON {
+		content = append(content, mcp.NewTextContent(formatMetrics(resp)))
+	}
+	if req.OutputFormat == OutputFormatJSON || req.OutputFormat == OutputFormatBoth {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return errorResult("Error rendering output: %v", err), nil
+		}
+		content = append(content, mcp.NewTextContent(string(data)))
+	}
+	return &mcp.CallToolResult{Content: content}, nil
+}
+
+// isMetricKey reports whether key is a known sort_by value
+func isMetricKey(key string) bool {
+	for _, k := range metricKeys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// functionMetrics computes the metrics of every function of the graph,
+// ordered by ID; it stops with a cancelledError once ctx is done
+func (a *analysis) functionMetrics(ctx context.Context, nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge) ([]MCPFunctionMetrics, error) {
+	ids := sortedNodeIDs(nodeMap)
+	out := make(map[string][]string)
+	in := make(map[string][]string)
+	for _, ed := range sortedEdges(edgeMap) {
+		if ed.Caller == ed.Callee {
+			continue
+		}
+		out[ed.Caller] = append(out[ed.Caller], ed.Callee)
+		in[ed.Callee] = append(in[ed.Callee], ed.Caller)
+	}
+
+	// Depth from the roots present in the filtered graph
+	var roots []string
+	for _, n := range a.depthRoots() {
+		if id := n.Func.String(); nodeMap[id] != nil {
+			roots = append(roots, id)
+		}
+	}
+	if len(roots) == 0 {
+		for _, id := range ids {
+			if len(in[id]) == 0 {
+				roots = append(roots, id)
+			}
+		}
+	}
+	depths := bfsDistances(roots, out)
+	betweenness, err := betweennessCentrality(ctx, ids, out)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]MCPFunctionMetrics, 0, len(ids))
+	for i, id := range ids {
+		if i%contextCheckInterval == 0 {
+			if err := checkContext(ctx, phaseMetrics); err != nil {
+				return nil, err
+			}
+		}
+		n := nodeMap[id]
+		m := MCPFunctionMetrics{
+			Func:        id,
+			PackagePath: n.PackagePath,
+			File:        n.File,
+			Line:        n.Line,
+			FanIn:       len(in[id]),
+			FanOut:      len(out[id]),
+			Callers:     len(bfsDistances([]string{id}, in)) - 1,
+			Callees:     len(bfsDistances([]string{id}, out)) - 1,
+			Betweenness: math.Round(betweenness[id]*1e4) / 1e4,
+		}
+		if d, ok := depths[id]; ok {
+			m.Depth = &d
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// bfsDistances returns the number of edges from the nearest source to every
+// node reachable through next
+func bfsDistances(sources []string, next map[string][]string) map[string]int {
+	dist := make(map[string]int, len(sources))
+	queue := make([]string, 0, len(sources))
+	for _, s := range sources {
+		if _, seen := dist[s]; !seen {
+			dist[s] = 0
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, n := range next[id] {
+			if _, seen := dist[n]; !seen {
+				dist[n] = dist[id] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+	return dist
+}
+
+// betweennessCentrality computes the directed betweenness of every node with
+// Brandes' algorithm, normalized by the (n-1)(n-2) ordered pairs of other nodes
+func betweennessCentrality(ctx context.Context, ids []string, out map[string][]string) (map[string]float64, error) {
+	cb := make(map[string]float64, len(ids))
+	for i, s := range ids {
+		if i%contextCheckInterval == 0 {
+			if err := checkContext(ctx, phaseMetrics); err != nil {
+				return nil, err
+			}
+		}
+		var order []string
+		preds := make(map[string][]string)
+		sigma := map[string]float64{s: 1}
+		dist := map[string]int{s: 0}
+		queue := []string{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			order = append(order, v)
+			for _, w := range out[v] {
+				if _, seen := dist[w]; !seen {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+		delta := make(map[string]float64, len(order))
+		for i := len(order) - 1; i > 0; i-- {
+			w := order[i]
+			for _, v := range preds[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			cb[w] += delta[w]
+		}
+	}
+	if n := len(ids); n > 2 {
+		for id := range cb {
+			cb[id] /= float64((n - 1) * (n - 2))
+		}
+	}
+	return cb, nil
+}
+
+// sortFunctionMetrics orders metrics by key, highest first, then by name
+func sortFunctionMetrics(metrics []MCPFunctionMetrics, key string) {
+	value := func(m MCPFunctionMetrics) float64 {
+		switch key {
+		case MetricFanIn:
+			return float64(m.FanIn)
+		case MetricFanOut:
+			return float64(m.FanOut)
+		case MetricCallers:
+			return float64(m.Callers)
+		case MetricCallees:
+			return float64(m.Callees)
+		}
+		return m.Betweenness
+	}
+	sort.SliceStable(metrics, func(i, j int) bool {
+		vi, vj := value(metrics[i]), value(metrics[j])
+		if vi != vj {
+			return vi > vj
+		}
+		return metrics[i].Func < metrics[j].Func
+	})
+}
+
+// packageMetrics computes the coupling of every package of the graph, most
+// coupled (Ca + Ce) first
+func packageMetrics(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge) []MCPPackageMetrics {
+	funcs := make(map[string]int)
+	for _, n := range nodeMap {
+		funcs[n.PackagePath]++
+	}
+	afferent := make(map[string]map[string]bool)
+	efferent := make(map[string]map[string]bool)
+	for _, ed := range edgeMap {
+		from, to := nodeMap[ed.Caller].PackagePath, nodeMap[ed.Callee].PackagePath
+		if from == to {
+			continue
+		}
+		if efferent[from] == nil {
+			efferent[from] = make(map[string]bool)
+		}
+		if afferent[to] == nil {
+			afferent[to] = make(map[string]bool)
+		}
+		efferent[from][to] = true
+		afferent[to][from] = true
+	}
+
+	metrics := make([]MCPPackageMetrics, 0, len(funcs))
+	for path, n := range funcs {
+		m := MCPPackageMetrics{Path: path, Functions: n, Afferent: len(afferent[path]), Efferent: len(efferent[path])}
+		if total := m.Afferent + m.Efferent; total > 0 {
+			m.Instability = math.Round(float64(m.Efferent)/float64(total)*100) / 100
+		}
+		metrics = append(metrics, m)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		ci, cj := metrics[i].Afferent+metrics[i].Efferent, metrics[j].Afferent+metrics[j].Efferent
+		if ci != cj {
+			return ci > cj
+		}
+		return metrics[i].Path < metrics[j].Path
+	})
+	return metrics
+}
+
+// formatMetrics renders the top functions and the package coupling as Markdown tables
+func formatMetrics(resp *MCPMetricsResponse) string {
+	var sb strings.Builder
+	for _, w := range resp.Warnings {
+		sb.WriteString("> warning: " + w + "\n\n")
+	}
+	sb.WriteString(fmt.Sprintf("## Top %d of %d functions by %s (algo: %s)\n\n", len(resp.Functions), resp.Total, resp.SortBy, resp.Algorithm))
+	sb.WriteString("| # | Function | Fan-in | Fan-out | Callers | Callees | Depth | Betweenness |\n")
+	sb.WriteString("|---|---|---|---|---|---|---|---|\n")
+	for i, m := range resp.Functions {
+		depth := "-"
+		if m.Depth != nil {
+			depth = fmt.Sprint(*m.Depth)
+		}
+		sb.WriteString(fmt.Sprintf("| %d | `%s` | %d | %d | %d | %d | %s | %.4f |\n",
+			i+1, m.Func, m.FanIn, m.FanOut, m.Callers, m.Callees, depth, m.Betweenness))
+	}
+	sb.WriteString("\n## Package coupling\n\n")
+	sb.WriteString("| Package | Functions | Ca | Ce | Instability |\n")
+	sb.WriteString("|---|---|---|---|---|\n")
+	for _, p := range resp.Packages {
+		sb.WriteString(fmt.Sprintf("| `%s` | %d | %d | %d | %.2f |\n", p.Path, p.Functions, p.Afferent, p.Efferent, p.Instability))
+	}
+	return sb.String()
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/emicklei/dot"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Supported values of the output_format parameter
+const (
+	OutputFormatMermaid = "mermaid"
+	OutputFormatJSON    = "json"
+	OutputFormatBoth    = "both"
+	OutputFormatDot     = "dot"
+	OutputFormatSVG     = "svg"
+)
+
+var outputFormats = []string{OutputFormatMermaid, OutputFormatJSON, OutputFormatBoth, OutputFormatDot, OutputFormatSVG}
+
+// graphResult is the filtered graph produced by the generators, with its Mermaid and DOT renderings
+type graphResult struct {
+	mermaid string
+	dot     *dot.Graph
+	data    MCPCallgraphData
+	stats   MCPCallgraphStats
+	notes   []string // what the output budget elided, and how to request it
+	group   []string // clusters drawn by the SVG output
+}
+
+// isValidOutputFormat reports whether format is empty (default) or a known output format
+func isValidOutputFormat(format string) bool {
+	if format == "" {
+		return true
+	}
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// newGraphData flattens the node and edge maps collected during traversal,
+// nodes sorted by ID and edges by caller then callee
+func newGraphData(nodeMap map[string]*MCPCallgraphNode, edgeMap map[string]*MCPCallgraphEdge) MCPCallgraphData {
+	data := MCPCallgraphData{
+		Nodes: make([]MCPCallgraphNode, 0, len(nodeMap)),
+		Edges: make([]MCPCallgraphEdge, 0, len(edgeMap)),
+	}
+	for _, id := range sortedNodeIDs(nodeMap) {
+		data.Nodes = append(data.Nodes, *nodeMap[id])
+	}
+	for _, e := range sortedEdges(edgeMap) {
+		data.Edges = append(data.Edges, *e)
+	}
+	return data
+}
+
+// sortedNodeIDs returns the node IDs in ascending order
+func sortedNodeIDs(nodeMap map[string]*MCPCallgraphNode) []string {
+	ids := make([]string, 0, len(nodeMap))
+	for id := range nodeMap {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// sortedEdges returns the edges ordered by caller, then callee
+func sortedEdges(edgeMap map[string]*MCPCallgraphEdge) []*MCPCallgraphEdge {
+	edges := make([]*MCPCallgraphEdge, 0, len(edgeMap))
+	for _, e := range edgeMap {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Caller != edges[j].Caller {
+			return edges[i].Caller < edges[j].Caller
+		}
+		return edges[i].Callee < edges[j].Callee
+	})
+	return edges
+}
+
+// newCallgraphResponse builds the JSON model from the request and the filters actually applied
+func newCallgraphResponse(a *analysis, req MCPCallgraphRequest, result *graphResult) *MCPCallgraphResponse {
+	var focus *string
+	if a.opts.focus != "" {
+		f := a.opts.focus
+		focus = &f
+	}
+	resp := &MCPCallgraphResponse{
+		Algorithm: string(a.opts.algo),
+		Focus:     focus,
+		Filters: MCPCallgraphFilters{
+			Limit:       nonNil(a.opts.limit),
+			Ignore:      nonNil(a.opts.ignore),
+			Include:     nonNil(a.opts.include),
+			NoStd:       a.opts.nostd,
+			NoInter:     a.opts.nointer,
+			Group:       nonNil(a.opts.group),
+			MaxDep:      a.opts.maxDep,
+			Symbol:      req.Symbol,
+			Direction:   req.Direction,
+			EdgeKinds:   a.opts.edgeKinds,
+			Granularity: a.opts.granularity,
+		},
+		Stats:    result.stats,
+		Graph:    result.data,
+		Warnings: a.warnings,
+		Notes:    result.notes,
+	}
+	if req.Symbol != "" {
+		resp.Filters.MaxDepUp = a.opts.maxDepUp
+		resp.Filters.MaxDepDown = a.opts.maxDepDown
+	}
+	return resp
+}
+
+// nonNil makes empty filter lists marshal as [] instead of null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// renderOutput returns the tool content for the requested output format
+func renderOutput(format string, result *graphResult, resp *MCPCallgraphResponse) ([]mcp.Content, error) {
+	switch format {
+	case "", OutputFormatMermaid:
+		return []mcp.Content{mcp.NewTextContent(withComments(result.mermaid, "%% ", "", resp))}, nil
+	case OutputFormatJSON:
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.Content{mcp.NewTextContent(string(data))}, nil
+	case OutputFormatBoth:
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.Content{
+			mcp.NewTextContent(withComments(result.mermaid, "%% ", "", resp)),
+			mcp.NewTextContent(string(data)),
+		}, nil
+	case OutputFormatDot:
+		return []mcp.Content{mcp.NewTextContent(withComments(result.dot.String(), "// ", "", resp))}, nil
+	case OutputFormatSVG:
+		return []mcp.Content{mcp.NewTextContent(withComments(renderSVG(result.data, result.group), "<!-- ", " -->", resp))}, nil
+	default:
+		return nil, fmt.Errorf("invalid output format: %s", format)
+	}
+}
+
+// withComments prepends each warning and appends each budget note to text,
+// as comments of its format
+func withComments(text, open, close string, resp *MCPCallgraphResponse) string {
+	if len(resp.Warnings) == 0 && len(resp.Notes) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	for _, w := range resp.Warnings {
+		sb.WriteString(open + "warning: " + w + close + "\n")
+	}
+	sb.WriteString(text)
+	if len(resp.Notes) > 0 && !strings.HasSuffix(text, "\n") {
+		sb.WriteString("\n")
+	}
+	for _, n := range resp.Notes {
+		sb.WriteString(open + "note: " + n + close + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/callgraph"
+)
+
+// findPositionNode resolves a source position to the innermost function of the
+// callgraph enclosing it, the way an editor's call hierarchy does. file may be
+// absolute, relative to dir, or a path suffix such as "pkg/server.go". Without
+// a column, any function overlapping the line qualifies.
+func (a *analysis) findPositionNode(dir, file string, line, column int) (*callgraph.Node, error) {
+	tf, err := a.findTokenFile(dir, file)
+	if err != nil {
+		return nil, err
+	}
+	if line > tf.LineCount() {
+		return nil, fmt.Errorf("line %d is out of range: %s has %d lines", line, tf.Name(), tf.LineCount())
+	}
+
+	// The searched range is the whole line, or a single offset with a column
+	from := tf.LineStart(line)
+	to := token.Pos(tf.Base() + tf.Size())
+	if line < tf.LineCount() {
+		to = tf.LineStart(line+1) - 1
+	}
+	if column > 0 {
+		if from+token.Pos(column-1) > to {
+			return nil, fmt.Errorf("column %d is out of range on %s:%d", column, tf.Name(), line)
+		}
+		from += token.Pos(column - 1)
+		to = from
+	}
+
+	// Keep the smallest enclosing functions; instances of a generic function share its syntax
+	var matches []*callgraph.Node
+	var bestSize token.Pos
+	for fn, n := range a.callgraph.Nodes {
+		if fn == nil || fn.Syntax() == nil || isSyntheticFunc(fn) {
+			continue
+		}
+		syntax := fn.Syntax()
+		if a.prog.Fset.File(syntax.Pos()) != tf || syntax.Pos() > to || syntax.End() < from {
+			continue
+		}
+		size := syntax.End() - syntax.Pos()
+		switch {
+		case matches == nil || size < bestSize:
+			matches, bestSize = []*callgraph.Node{n}, size
+		case size == bestSize:
+			matches = append(matches, n)
+		}
+	}
+	matches = preferMatches(matches, isInstantiated)
+
+	where := fmt.Sprintf("%s:%d", tf.Name(), line)
+	if column > 0 {
+		where = fmt.Sprintf("%s:%d", where, column)
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no function in the callgraph encloses %s (it may be unreachable with algo %s)", where, a.opts.algo)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, a.ambiguousError(where, matches)
+	}
+}
+
+// findTokenFile returns the loaded file matching file, resolved against dir
+func (a *analysis) findTokenFile(dir, file string) (*token.File, error) {
+	path := file
+	if !filepath.IsAbs(path) && dir != "" {
+		path = filepath.Join(dir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	suffix := string(filepath.Separator) + filepath.Clean(file)
+
+	var exact *token.File
+	var suffixed []*token.File
+	a.prog.Fset.Iterate(func(tf *token.File) bool {
+		name := filepath.Clean(tf.Name())
+		if name == path {
+			exact = tf
+			return false
+		}
+		if strings.HasSuffix(name, suffix) {
+			suffixed = append(suffixed, tf)
+		}
+		return true
+	})
+	if exact != nil {
+		return exact, nil
+	}
+	switch len(suffixed) {
+	case 0:
+		return nil, fmt.Errorf("file not found among the analyzed packages: %s", file)
+	case 1:
+		return suffixed[0], nil
+	default:
+		var names []string
+		for _, tf := range suffixed {
+			names = append(names, tf.Name())
+		}
+		return nil, fmt.Errorf("file %s is ambiguous; use a longer path or an absolute one:\n  %s", file, strings.Join(names, "\n  "))
+	}
+}
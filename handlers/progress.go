@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Analysis steps reported as MCP progress; a cache hit skips straight to filtering
+const (
+	stepLoad = iota + 1
+	stepBuild
+	stepCallgraph
+	stepFilter
+	stepRender
+	progressSteps = stepRender
+)
+
+// loggerName identifies this server in MCP logging notifications
+const loggerName = "callgraph-mcp"
+
+type progressKey struct{}
+
+// withProgress attaches the client's progress token (if any) to ctx
+func withProgress(ctx context.Context, request mcp.CallToolRequest) context.Context {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressKey{}, request.Params.Meta.ProgressToken)
+}
+
+// progressf reports that the request reached step, as a notifications/progress
+// for clients that sent a progressToken and as a log message
+func progressf(ctx context.Context, step int, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	ctxLogf(ctx, "[%d/%d] %s", step, progressSteps, msg)
+
+	srv := server.ServerFromContext(ctx)
+	token := ctx.Value(progressKey{})
+	if srv == nil || token == nil {
+		return
+	}
+	err := srv.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+		"progressToken": token,
+		"progress":      step,
+		"total":         progressSteps,
+		"message":       msg,
+	})
+	if err != nil {
+		logf("progress notification failed: %v", err)
+	}
+}
+
+// ctxLogf logs like logf and forwards the message to the client as an MCP
+// logging notification; the client's logging/setLevel decides whether it is sent
+func ctxLogf(ctx context.Context, format string, args ...interface{}) {
+	logf(format, args...)
+	srv := server.ServerFromContext(ctx)
+	if srv == nil || server.ClientSessionFromContext(ctx) == nil {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	_ = srv.SendLogMessageToClient(ctx, mcp.NewLoggingMessageNotification(mcp.LoggingLevelInfo, loggerName, msg))
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Names of the code-navigation prompts
+const (
+	PromptExplainArchitecture = "explainArchitecture"
+	PromptChangeImpact        = "changeImpact"
+	PromptTraceRequest        = "traceRequest"
+)
+
+// promptOutputBytes bounds the graph embedded in a prompt; larger graphs are
+// summarized by the output budget
+const promptOutputBytes = 40000
+
+// promptGraph runs callHierarchy with the dir and moduleArgs prompt arguments
+// plus params, and returns its Mermaid output followed by a pointer to the
+// JSON resource of the graph when one was registered
+func promptGraph(ctx context.Context, args map[string]string, params map[string]interface{}) (string, error) {
+	moduleArgs := splitModuleArgs(args["moduleArgs"])
+	if len(moduleArgs) == 0 {
+		moduleArgs = []string{"./..."}
+	}
+	arguments := map[string]interface{}{
+		"dir":              args["dir"],
+		"moduleArgs":       moduleArgs,
+		"max_output_bytes": promptOutputBytes,
+	}
+	for k, v := range params {
+		arguments[k] = v
+	}
+
+	result, err := HandleCallgraphRequest(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: arguments},
+	})
+	if err != nil {
+		return "", err
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError {
+		return "", errors.New(text)
+	}
+	graph := "```mermaid\n" + strings.TrimSuffix(text, "\n") + "\n```\n"
+	for _, c := range result.Content[1:] {
+		if link, ok := c.(mcp.ResourceLink); ok && link.MIMEType == graphViewMIMETypes[GraphViewJSON] {
+			graph += fmt.Sprintf("\nThe graph with call sites is available as the resource %s; "+
+				"read it with other symbol and direction query parameters to explore further.\n", link.URI)
+			break
+		}
+	}
+	return graph, nil
+}
+
+// requireSymbol reports an error when the symbol prompt argument is missing
+func requireSymbol(args map[string]string) error {
+	if args["symbol"] == "" {
+		return errors.New("symbol is required (use the findSymbol tool to look names up)")
+	}
+	return nil
+}
+
+// promptDirection returns the direction argument, or def when it is not set
+func promptDirection(args map[string]string, def string) (string, error) {
+	switch d := args["direction"]; d {
+	case "":
+		return def, nil
+	case "downstream", "upstream", "both":
+		return d, nil
+	default:
+		return "", fmt.Errorf("invalid direction %q (expected downstream, upstream or both)", d)
+	}
+}
+
+// promptResult wraps text as the user message of a prompt
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	})
+}
+
+// HandleExplainArchitecturePrompt embeds the package-level callgraph of a
+// codebase in a request to explain its architecture
+func HandleExplainArchitecturePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+	params := map[string]interface{}{
+		"granularity": GranularityPkg,
+		"max_dep":     0,
+	}
+	if prefix := args["limit_prefix"]; prefix != "" {
+		params["limit_prefix"] = []string{prefix}
+	}
+	graph, err := promptGraph(ctx, args, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Explain the architecture of the Go code in %s.\n\n", args["dir"])
+	sb.WriteString("Below is its package-level callgraph: each node is a package and each edge is labeled with the number of calls between two packages.\n\n")
+	sb.WriteString(graph)
+	sb.WriteString("\nUsing this graph:\n" +
+		"1. Identify the entry points and the layers (e.g. transport, service, storage) and which packages belong to each.\n" +
+		"2. Describe how a typical request flows between the layers.\n" +
+		"3. Point out the central packages most others depend on, and any calls that go against the layering or form cycles.\n" +
+		"Use the callHierarchy tool with granularity func on a single package (limit_prefix) when you need function-level detail.\n")
+	return promptResult("Architecture overview of "+args["dir"], sb.String()), nil
+}
+
+// HandleChangeImpactPrompt embeds the callers of a symbol in a request to
+// assess what breaks if it changes
+func HandleChangeImpactPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+	if err := requireSymbol(args); err != nil {
+		return nil, err
+	}
+	direction, err := promptDirection(args, "upstream")
+	if err != nil {
+		return nil, err
+	}
+	graph, err := promptGraph(ctx, args, map[string]interface{}{
+		"symbol":    args["symbol"],
+		"direction": direction,
+		"nointer":   false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "I want to change %s in %s. Find out what could break.\n\n", args["symbol"], args["dir"])
+	fmt.Fprintf(&sb, "Below is the %s callgraph of %s, through static calls, interface dispatch and function values.\n\n", direction, args["symbol"])
+	sb.WriteString(graph)
+	sb.WriteString("\nUsing this graph:\n" +
+		"1. List the direct callers and the exported functions, handlers and tests that transitively depend on the symbol, grouped by package.\n" +
+		"2. Flag callers reached only through interface dispatch or closures (dotted edges), since their dependency is easy to miss.\n" +
+		"3. Say which call sites would need to be updated for a signature or behavior change, and which tests should be run.\n" +
+		"Nodes marked '+N more callers' were cut off by the depth limit; call callHierarchy on them to continue.\n")
+	return promptResult("Impact of changing "+args["symbol"], sb.String()), nil
+}
+
+// HandleTraceRequestPrompt embeds the callees of an entry point, such as an
+// HTTP handler, in a request to trace a request down to its storage
+func HandleTraceRequestPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+	if err := requireSymbol(args); err != nil {
+		return nil, err
+	}
+	direction, err := promptDirection(args, "downstream")
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]interface{}{
+		"symbol":    args["symbol"],
+		"direction": direction,
+		"nointer":   false,
+	}
+	sink := args["sink"]
+	if sink != "" {
+		// The sink is often a standard library package such as database/sql
+		params["nostd"] = false
+	}
+	graph, err := promptGraph(ctx, args, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Trace a request handled by %s in %s down to where it reads or writes data.\n\n", args["symbol"], args["dir"])
+	fmt.Fprintf(&sb, "Below is the %s callgraph of %s.\n\n", direction, args["symbol"])
+	sb.WriteString(graph)
+	sb.WriteString("\nUsing this graph:\n" +
+		"1. Follow the calls from the handler through validation, business logic and data access, in the order a request goes through them.\n")
+	if sink != "" {
+		fmt.Fprintf(&sb, "2. Focus on the paths that end in packages matching %q; list each one with the file:line of its calls.\n", sink)
+	} else {
+		sb.WriteString("2. Identify the calls that reach a database, cache, queue or external service; list each path with the file:line of its calls.\n")
+	}
+	sb.WriteString("3. Note goroutines (thick edges) and deferred calls that run outside the main request path.\n" +
+		"Use the callPath tool for the exact path between two functions of the graph.\n")
+	return promptResult("Request trace from "+args["symbol"], sb.String()), nil
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// errorResult builds an error tool result with a formatted message
+func errorResult(format string, args ...interface{}) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewTextContent(fmt.Sprintf(format, args...)),
+		},
+		IsError: true,
+	}
+}
+
+// decodeArguments converts the raw tool arguments into v
+func decodeArguments(request mcp.CallToolRequest, v interface{}) error {
+	// Convert arguments to JSON bytes first
+	argsBytes, err := json.Marshal(request.Params.Arguments)
+	if err != nil {
+		return fmt.Errorf("Error marshaling arguments: %v", err)
+	}
+	if err := json.Unmarshal(argsBytes, v); err != nil {
+		return fmt.Errorf("Error parsing arguments: %v", err)
+	}
+	return nil
+}
+
+// applyDefaults fills in the schema defaults that differ from Go zero values
+func (req *MCPCallgraphRequest) applyDefaults(request mcp.CallToolRequest) {
+	// Set defaults
+	if req.Algo == "" {
+		req.Algo = "rta"
+	}
+	if len(req.Group) == 0 {
+		req.Group = []string{"pkg"}
+	}
+
+	// Set debug flag
+	if req.Debug {
+		*debugFlag = true
+	}
+
+	// Apply default values for boolean fields to match schema defaults
+	// Note: Go's zero value for bool is false, but our schema defaults are different
+	if args, ok := request.Params.Arguments.(map[string]interface{}); ok {
+		// Check if nostd was explicitly provided in the request
+		if _, exists := args["nostd"]; !exists {
+			req.NoStd = true
+		}
+		// Check if nointer was explicitly provided in the request
+		if _, exists := args["nointer"]; !exists {
+			req.NoInter = true
+		}
+		// Dynamic default for max_dep depending on symbol presence
+		if _, exists := args["max_dep"]; !exists {
+			req.MaxDep = req.defaultMaxDep()
+		}
+		// Per-direction traversal limits default to max_dep
+		if _, exists := args["max_dep_upstream"]; !exists {
+			req.MaxDepUpstream = req.MaxDep
+		}
+		if _, exists := args["max_dep_downstream"]; !exists {
+			req.MaxDepDownstream = req.MaxDep
+		}
+	}
+}
+
+// defaultMaxDep is the max_dep default: deeper for a traversal from a symbol
+// than for a whole package graph
+func (req *MCPCallgraphRequest) defaultMaxDep() int {
+	if req.Symbol != "" || req.File != "" {
+		return 7
+	}
+	return 4
+}
+
+// newRequestAnalysis maps req to render options and loads its (possibly cached) analysis
+func newRequestAnalysis(ctx context.Context, req MCPCallgraphRequest) (*analysis, error) {
+	// Refuse to load code outside the allowed roots
+	if err := checkWorkspace(req.Dir, req.ModuleArgs); err != nil {
+		return nil, err
+	}
+
+	// Map MCP request to internal analysis options
+	opts := mapMCPRequestToRenderOpts(req)
+
+	// Initialize analysis
+	a := &analysis{opts: opts}
+
+	// Perform analysis (reusing a cached program when nothing changed)
+	algo := CallGraphType(req.Algo)
+	if err := a.LoadAnalysis(ctx, algo, req.Dir, req.Tests, req.ModuleArgs, req.Tags, req.Refresh); err != nil {
+		var cancelled *cancelledError
+		if errors.As(err, &cancelled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Analysis failed: %v", err)
+	}
+
+	// Process list arguments (comma-separated strings to slices)
+	if err := a.ProcessListArgs(); err != nil {
+		return nil, fmt.Errorf("Error processing arguments: %v", err)
+	}
+	progressf(ctx, stepFilter, "callgraph has %d nodes; filtering", len(a.callgraph.Nodes))
+	return a, nil
+}
+
+// Analysis phases, as reported when a request is cancelled or times out
+const (
+	phaseLoad      = "loading packages"
+	phaseBuild     = "building SSA"
+	phaseCallgraph = "computing callgraph"
+	phaseFilter    = "filtering"
+	phaseMetrics   = "computing metrics"
+	phasePaths     = "enumerating paths"
+)
+
+// contextCheckInterval is how many iterations of a long graph computation
+// run between two checks of the request context
+const contextCheckInterval = 64
+
+// defaultTimeoutMs is the server-wide request timeout in milliseconds (0: none)
+var defaultTimeoutMs atomic.Int64
+
+// SetDefaultTimeout sets the timeout applied to requests without timeout_ms (0 disables it)
+func SetDefaultTimeout(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	defaultTimeoutMs.Store(d.Milliseconds())
+}
+
+// withRequestTimeout bounds ctx by timeout_ms, or by the server default when it is not set
+func withRequestTimeout(ctx context.Context, req MCPCallgraphRequest) (context.Context, context.CancelFunc) {
+	ms := int64(req.TimeoutMs)
+	if ms <= 0 {
+		ms = defaultTimeoutMs.Load()
+	}
+	if ms <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+}
+
+// cancelledError reports the phase at which a cancelled or timed out request stopped
+type cancelledError struct {
+	phase string
+	err   error
+}
+
+func (e *cancelledError) Error() string {
+	verb := "cancelled"
+	if errors.Is(e.err, context.DeadlineExceeded) {
+		verb = "timed out"
+	}
+	return fmt.Sprintf("analysis %s after phase %q", verb, e.phase)
+}
+
+func (e *cancelledError) Unwrap() error { return e.err }
+
+// checkContext returns a cancelledError for phase once ctx is done
+func checkContext(ctx context.Context, phase string) error {
+	if err := ctx.Err(); err != nil {
+		ctxLogf(ctx, "request stopped after phase %q: %v", phase, err)
+		return &cancelledError{phase: phase, err: err}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// GraphResourceTemplate addresses the graphs analyzed by callHierarchy; symbol
+// and direction turn a graph into a traversal from symbol
+const GraphResourceTemplate = "callgraph://{workspace}/{hash}/{view}{?symbol,direction}"
+
+// Views of a graph resource
+const (
+	GraphViewMermaid  = "mermaid"
+	GraphViewJSON     = "json"
+	GraphViewDot      = "dot"
+	GraphViewPackages = "packages" // JSON of the package-level graph
+)
+
+var graphViews = []string{GraphViewMermaid, GraphViewJSON, GraphViewDot, GraphViewPackages}
+
+// graphViewMIMETypes are the MIME types of the resource views
+var graphViewMIMETypes = map[string]string{
+	GraphViewMermaid:  "text/vnd.mermaid",
+	GraphViewJSON:     "application/json",
+	GraphViewDot:      "text/vnd.graphviz",
+	GraphViewPackages: "application/json",
+}
+
+// maxGraphResources is the number of graphs kept as resources; the oldest
+// are removed from the server beyond it
+const maxGraphResources = 64
+
+// maxGraphTraversals is the number of traversals of a graph kept as
+// resources; the least recently used are removed beyond it and stay
+// readable through the template
+const maxGraphTraversals = 8
+
+// graphTraversal is the symbol and direction of a traversal resource
+type graphTraversal struct {
+	symbol, direction string
+}
+
+// graphEntry is a graph registered as resources: the callHierarchy request
+// without its traversal parameters
+type graphEntry struct {
+	workspace  string
+	req        MCPCallgraphRequest
+	args       map[string]interface{}       // arguments applyDefaults must keep when the graph is read
+	uris       map[string]*server.MCPServer // registered resources and the server holding them
+	traversals []graphTraversal             // registered traversals, least recently used first
+}
+
+// graphRegistry maps graph hashes to their requests, oldest first in order
+var graphRegistry = struct {
+	mu      sync.Mutex
+	entries map[string]*graphEntry
+	order   []string
+}{entries: make(map[string]*graphEntry)}
+
+// baseGraphRequest strips req of everything that does not change the graph
+// (traversal start, output format, timeouts) and makes its dir absolute. The
+// depths left at their defaults, which depend on symbol, are cleared too; the
+// returned arguments name the options applyDefaults must keep.
+func baseGraphRequest(req MCPCallgraphRequest) (MCPCallgraphRequest, map[string]interface{}) {
+	args := map[string]interface{}{"nostd": req.NoStd, "nointer": req.NoInter}
+	if req.MaxDep != req.defaultMaxDep() {
+		args["max_dep"] = req.MaxDep
+	}
+	if req.MaxDepUpstream != req.MaxDep {
+		args["max_dep_upstream"] = req.MaxDepUpstream
+	}
+	if req.MaxDepDownstream != req.MaxDep {
+		args["max_dep_downstream"] = req.MaxDepDownstream
+	}
+	base := req
+	base.MaxDep, base.MaxDepUpstream, base.MaxDepDownstream = 0, 0, 0
+	base.Symbol, base.File, base.Line, base.Column, base.Direction = "", "", 0, 0, ""
+	base.OutputFormat = ""
+	base.TimeoutMs = 0
+	base.Refresh = false
+	base.Debug = false
+	dir := base.Dir
+	if dir == "" {
+		dir = "."
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		base.Dir = abs
+	}
+	return base, args
+}
+
+// workspaceName names the workspace of dir in resource URIs: its base name,
+// restricted to characters valid in a URI host
+func workspaceName(dir string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' || r == '_' {
+			return r
+		}
+		return '-'
+	}, filepath.Base(dir))
+	if name == "" || name == "." || name == "-" {
+		return "workspace"
+	}
+	return name
+}
+
+// graphHash identifies a base request and its kept arguments by the hash of their JSON encoding
+func graphHash(base MCPCallgraphRequest, args map[string]interface{}) string {
+	data, _ := json.Marshal(struct {
+		Request   MCPCallgraphRequest    `json:"request"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}{base, args})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// graphURI builds the resource URI of a view, with the traversal parameters if symbol is set
+func graphURI(workspace, hash, view, symbol, direction string) string {
+	uri := "callgraph://" + workspace + "/" + hash + "/" + view
+	if symbol != "" {
+		// Query parameters in template order, spaces as %20 as RFC 6570 expects
+		uri += "?symbol=" + strings.ReplaceAll(url.QueryEscape(symbol), "+", "%20")
+		if direction != "" {
+			uri += "&direction=" + url.QueryEscape(direction)
+		}
+	}
+	return uri
+}
+
+// registerGraphResources registers the views of the graph of req as
+// resources of the server handling ctx, and returns links to them. Without
+// a server (handlers called directly) nothing is registered.
+func registerGraphResources(ctx context.Context, req MCPCallgraphRequest) []mcp.Content {
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return nil
+	}
+	base, args := baseGraphRequest(req)
+	workspace := workspaceName(base.Dir)
+	hash := graphHash(base, args)
+
+	graphRegistry.mu.Lock()
+	e, ok := graphRegistry.entries[hash]
+	if !ok {
+		e = &graphEntry{workspace: workspace, req: base, args: args, uris: make(map[string]*server.MCPServer)}
+		graphRegistry.entries[hash] = e
+	} else {
+		// Move the graph to the back of the eviction order
+		for i, h := range graphRegistry.order {
+			if h == hash {
+				graphRegistry.order = append(graphRegistry.order[:i], graphRegistry.order[i+1:]...)
+				break
+			}
+		}
+	}
+	graphRegistry.order = append(graphRegistry.order, hash)
+	stale := make(map[string]*server.MCPServer)
+	for len(graphRegistry.order) > maxGraphResources {
+		for uri, s := range graphRegistry.entries[graphRegistry.order[0]].uris {
+			stale[uri] = s
+		}
+		delete(graphRegistry.entries, graphRegistry.order[0])
+		graphRegistry.order = graphRegistry.order[1:]
+	}
+	if req.Symbol != "" {
+		// Move the traversal to the back of the graph's own eviction order
+		t := graphTraversal{req.Symbol, req.Direction}
+		for i, old := range e.traversals {
+			if old == t {
+				e.traversals = append(e.traversals[:i], e.traversals[i+1:]...)
+				break
+			}
+		}
+		e.traversals = append(e.traversals, t)
+		for len(e.traversals) > maxGraphTraversals {
+			old := e.traversals[0]
+			e.traversals = e.traversals[1:]
+			for _, view := range graphViews {
+				uri := graphURI(workspace, hash, view, old.symbol, old.direction)
+				if s, ok := e.uris[uri]; ok {
+					stale[uri] = s
+					delete(e.uris, uri)
+				}
+			}
+		}
+	}
+
+	title := "callgraph " + workspace
+	if req.Symbol != "" {
+		title += " " + req.Symbol + " " + req.Direction
+	}
+	var resources []server.ServerResource
+	var links []mcp.Content
+	for _, view := range graphViews {
+		uri := graphURI(workspace, hash, view, req.Symbol, req.Direction)
+		name := title + " (" + view + ")"
+		description := fmt.Sprintf("%s of moduleArgs %s in %s; re-read to pick up code changes (no subscriptions)", view, strings.Join(base.ModuleArgs, " "), base.Dir)
+		if _, ok := e.uris[uri]; !ok {
+			e.uris[uri] = srv
+			resources = append(resources, server.ServerResource{
+				Resource: mcp.NewResource(uri, name,
+					mcp.WithResourceDescription(description),
+					mcp.WithMIMEType(graphViewMIMETypes[view]),
+				),
+				Handler: HandleGraphResource,
+			})
+		}
+		links = append(links, mcp.NewResourceLink(uri, name, description, graphViewMIMETypes[view]))
+	}
+	graphRegistry.mu.Unlock()
+
+	for uri, s := range stale {
+		s.DeleteResources(uri)
+	}
+	if len(resources) > 0 {
+		srv.AddResources(resources...)
+	}
+	return links
+}
+
+// HandleGraphResource serves a view of a registered graph, as a traversal when
+// the URI has a symbol. The graph is filtered again from the cached analysis,
+// so unchanged code is not reloaded and edited code is picked up.
+func HandleGraphResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	start := time.Now()
+	uri := request.Params.URI
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "callgraph" {
+		return nil, fmt.Errorf("invalid graph resource URI %q", uri)
+	}
+	hash, view, ok := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	mimeType, known := graphViewMIMETypes[view]
+	if !ok || !known {
+		return nil, fmt.Errorf("invalid graph resource URI %q (expected callgraph://<workspace>/<hash>/<view> with view one of %s)", uri, strings.Join(graphViews, ", "))
+	}
+
+	graphRegistry.mu.Lock()
+	e, ok := graphRegistry.entries[hash]
+	graphRegistry.mu.Unlock()
+	if !ok || e.workspace != u.Host {
+		return nil, fmt.Errorf("unknown graph %s/%s: %w; run callHierarchy again to register it", u.Host, hash, server.ErrResourceNotFound)
+	}
+
+	req := e.req
+	query := u.Query()
+	req.Symbol = query.Get("symbol")
+	req.Direction = query.Get("direction")
+	switch req.Direction {
+	case "", "downstream", "upstream", "both":
+	default:
+		return nil, fmt.Errorf("invalid direction %q (expected downstream, upstream or both)", req.Direction)
+	}
+	if req.Direction != "" && req.Symbol == "" {
+		return nil, fmt.Errorf("direction requires symbol")
+	}
+	// Depths left at their defaults follow symbol, as in a tool call
+	req.applyDefaults(mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: e.args}})
+	switch view {
+	case GraphViewMermaid:
+		req.OutputFormat = OutputFormatMermaid
+	case GraphViewDot:
+		req.OutputFormat = OutputFormatDot
+	case GraphViewJSON:
+		req.OutputFormat = OutputFormatJSON
+	case GraphViewPackages:
+		req.OutputFormat = OutputFormatJSON
+		req.Granularity = GranularityPkg
+	}
+
+	ctx, cancel := withRequestTimeout(ctx, req)
+	defer cancel()
+	a, result, err := generateCallgraph(ctx, &req, start)
+	if err != nil {
+		return nil, err
+	}
+	content, err := renderOutput(req.OutputFormat, result, newCallgraphResponse(a, req, result))
+	if err != nil {
+		return nil, fmt.Errorf("Error rendering output: %v", err)
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: mimeType,
+		Text:     content[0].(mcp.TextContent).Text,
+	}}, nil
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// Supported values of the rta_roots parameter
+const (
+	RootsAuto     = "auto"     // main packages, falling back to exported (and test) functions
+	RootsMain     = "main"     // main packages only; fails on libraries
+	RootsExported = "exported" // exported functions and methods of the analyzed packages
+	RootsTests    = "tests"    // Test, Benchmark and Fuzz functions (requires tests=true)
+)
+
+// rtaRoots selects the functions RTA starts from, besides package initializers.
+// Explicit roots are added to those of the mode; in auto mode a program
+// without main packages falls back to its exported API (and tests, when
+// loaded) instead of failing, and the fallback is reported as a warning.
+func (a *analysis) rtaRoots(prog *ssa.Program, initial []*ssa.Package, tests bool) (roots []*ssa.Function, mainPkg *ssa.Package, warnings []string, err error) {
+	explicit, err := a.resolveRoots(prog, a.opts.roots)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	mode := a.opts.rtaRoots
+	if mode == "" {
+		mode = RootsAuto
+	}
+	switch mode {
+	case RootsAuto, RootsMain:
+		mains, err := mainPackages(prog.AllPackages())
+		if err == nil {
+			mainPkg = mains[0]
+			for _, main := range mains {
+				roots = append(roots, main.Func("main"))
+			}
+			break
+		}
+		if mode == RootsMain {
+			return nil, nil, nil, fmt.Errorf("%v; use rta_roots %q or %q, or list roots, to analyze a library", err, RootsExported, RootsTests)
+		}
+		if len(explicit) > 0 {
+			break
+		}
+		roots = declaredFuncs(prog, initial, true)
+		what := "exported functions and methods"
+		if tests {
+			roots = append(roots, testFuncs(prog, initial)...)
+			what += " and tests"
+		}
+		warnings = append(warnings, fmt.Sprintf("no main packages; RTA roots fall back to %d %s of the analyzed packages (set rta_roots or roots to choose them)", len(roots), what))
+	case RootsExported:
+		roots = declaredFuncs(prog, initial, true)
+	case RootsTests:
+		if !tests {
+			return nil, nil, nil, fmt.Errorf("rta_roots %q requires tests=true", RootsTests)
+		}
+		roots = testFuncs(prog, initial)
+	default:
+		return nil, nil, nil, fmt.Errorf("invalid rta_roots: %s", mode)
+	}
+	roots = append(roots, explicit...)
+	if len(roots) == 0 {
+		return nil, nil, nil, fmt.Errorf("no RTA roots found (rta_roots: %s)", mode)
+	}
+	return roots, mainPkg, warnings, nil
+}
+
+// resolveRoots resolves the explicit roots like the symbol parameter,
+// among the functions and methods declared anywhere in the program
+func (a *analysis) resolveRoots(prog *ssa.Program, symbols []string) ([]*ssa.Function, error) {
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+	var nodes []*callgraph.Node
+	for _, fn := range declaredFuncs(prog, prog.AllPackages(), false) {
+		nodes = append(nodes, &callgraph.Node{Func: fn})
+	}
+	var roots []*ssa.Function
+	for _, symbol := range symbols {
+		matches := bestSymbolMatches(symbol, nodes)
+		switch len(matches) {
+		case 1:
+			roots = append(roots, matches[0].Func)
+		case 0:
+			return nil, fmt.Errorf("RTA root not found: %s", symbol)
+		default:
+			return nil, a.ambiguousError(fmt.Sprintf("RTA root %q", symbol), matches)
+		}
+	}
+	return roots, nil
+}
+
+// declaredFuncs lists the package-level functions and the methods declared in
+// pkgs. Generic functions and methods of generic types are skipped: they
+// cannot be called without type arguments.
+func declaredFuncs(prog *ssa.Program, pkgs []*ssa.Package, exportedOnly bool) []*ssa.Function {
+	var funcs []*ssa.Function
+	for _, fn := range memberFuncs(prog, pkgs) {
+		if fn.TypeParams().Len() > 0 {
+			continue
+		}
+		if exportedOnly && (fn.Object() == nil || !fn.Object().Exported()) {
+			continue
+		}
+		funcs = append(funcs, fn)
+	}
+	return funcs
+}
+
+// memberFuncs lists the package-level functions and the methods declared in
+// pkgs, generic ones included
+func memberFuncs(prog *ssa.Program, pkgs []*ssa.Package) []*ssa.Function {
+	seen := make(map[*ssa.Function]bool)
+	var funcs []*ssa.Function
+	add := func(fn *ssa.Function) {
+		if fn == nil || seen[fn] {
+			return
+		}
+		seen[fn] = true
+		funcs = append(funcs, fn)
+	}
+	for _, p := range pkgs {
+		if p == nil {
+			continue
+		}
+		for _, member := range p.Members {
+			switch m := member.(type) {
+			case *ssa.Function:
+				add(m)
+			case *ssa.Type:
+				named, ok := m.Type().(*types.Named)
+				if !ok {
+					continue
+				}
+				for i := 0; i < named.NumMethods(); i++ {
+					add(prog.FuncValue(named.Method(i)))
+				}
+			}
+		}
+	}
+	return funcs
+}
+
+// testFuncs lists the Test, Benchmark and Fuzz functions declared in the
+// _test.go files of pkgs
+func testFuncs(prog *ssa.Program, pkgs []*ssa.Package) []*ssa.Function {
+	var funcs []*ssa.Function
+	for _, p := range pkgs {
+		if p == nil {
+			continue
+		}
+		for name, member := range p.Members {
+			fn, ok := member.(*ssa.Function)
+			if !ok || !strings.HasSuffix(prog.Fset.Position(fn.Pos()).Filename, "_test.go") {
+				continue
+			}
+			if isTestName(name, "Test") || isTestName(name, "Benchmark") || isTestName(name, "Fuzz") {
+				funcs = append(funcs, fn)
+			}
+		}
+	}
+	return funcs
+}
+
+// isTestName reports whether name is prefix followed by nothing or a
+// non-lowercase letter, the way go test recognizes test functions
+func isTestName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// SVG layout metrics in pixels
+const (
+	svgNodeHeight = 36
+	svgCharWidth  = 7
+	svgNodePadX   = 10
+	svgLayerGap   = 70
+	svgRowGap     = 14
+	svgMargin     = 20
+	svgLegendRow  = 18
+	svgClusterPad = 8  // space between a cluster border and its contents
+	svgClusterTop = 18 // height of a cluster label
+)
+
+// svgPalette holds node fill colors, assigned per package
+var svgPalette = []string{
+	"#dbeafe", "#dcfce7", "#fef3c7", "#fce7f3",
+	"#ede9fe", "#cffafe", "#fee2e2", "#e5e7eb",
+}
+
+// svgCluster is a pkg or type group drawn as a rectangle around its members
+type svgCluster struct {
+	label       string
+	depth       int   // 0 for the outermost clusters
+	members     []int // indexes of the member nodes
+	top, bottom float64
+	left, right float64
+}
+
+type svgNode struct {
+	node  MCPCallgraphNode
+	sub   string // second label line (file:line)
+	layer int
+	row   int
+	x, y  float64
+	w     float64
+}
+
+// renderSVG lays out the graph left-to-right with a simple layered
+// (Sugiyama-style) algorithm and renders it as a standalone SVG document.
+// It needs no external Graphviz binary. Nodes are clustered by package
+// and/or receiver type per group, like the Mermaid and DOT output: each
+// cluster takes a band of rows, so its members stay contiguous in every layer.
+func renderSVG(data MCPCallgraphData, group []string) string {
+	nodes := make([]*svgNode, 0, len(data.Nodes))
+	for _, n := range data.Nodes {
+		nodes = append(nodes, &svgNode{node: n, sub: nodeLocation(&n)})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].node.ID < nodes[j].node.ID })
+	index := make(map[string]int, len(nodes))
+	for i, n := range nodes {
+		index[n.node.ID] = i
+	}
+
+	// Adjacency of the original edges (deduplicated, self loops kept aside)
+	type edge struct{ from, to int }
+	var edges []edge
+	seen := make(map[edge]bool)
+	kinds := make(map[edge]string)
+	cycle := make(map[edge]bool)
+	out := make([][]int, len(nodes))
+	for _, e := range data.Edges {
+		from, ok1 := index[e.Caller]
+		to, ok2 := index[e.Callee]
+		if !ok1 || !ok2 || seen[edge{from, to}] {
+			continue
+		}
+		seen[edge{from, to}] = true
+		kinds[edge{from, to}] = e.Kind
+		cycle[edge{from, to}] = e.Cycle
+		edges = append(edges, edge{from, to})
+		if from != to {
+			out[from] = append(out[from], to)
+		}
+	}
+
+	// Break cycles: edges closing a DFS cycle are reversed for layering only
+	const (
+		white = iota
+		gray
+		black
+	)
+	color := make([]int, len(nodes))
+	dag := make([][]int, len(nodes))
+	var visit func(u int)
+	visit = func(u int) {
+		color[u] = gray
+		for _, v := range out[u] {
+			switch color[v] {
+			case white:
+				dag[u] = append(dag[u], v)
+				visit(v)
+			case gray:
+				dag[v] = append(dag[v], u)
+			default:
+				dag[u] = append(dag[u], v)
+			}
+		}
+		color[u] = black
+	}
+	for i := range nodes {
+		if color[i] == white {
+			visit(i)
+		}
+	}
+
+	// Longest-path layering in topological order
+	indeg := make([]int, len(nodes))
+	for u := range dag {
+		for _, v := range dag[u] {
+			indeg[v]++
+		}
+	}
+	queue := make([]int, 0, len(nodes))
+	for i := range nodes {
+		if indeg[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, v := range dag[u] {
+			if nodes[u].layer+1 > nodes[v].layer {
+				nodes[v].layer = nodes[u].layer + 1
+			}
+			indeg[v]--
+			if indeg[v] == 0 {
+				queue = append(queue, v)
+			}
+		}
+	}
+
+	maxLayer := 0
+	for _, n := range nodes {
+		if n.layer > maxLayer {
+			maxLayer = n.layer
+		}
+	}
+	layers := make([][]int, maxLayer+1)
+	for i, n := range nodes {
+		layers[n.layer] = append(layers[n.layer], i)
+	}
+	for _, l := range layers {
+		for r, i := range l {
+			nodes[i].row = r
+		}
+	}
+
+	// Reduce crossings with a few barycenter sweeps
+	preds := make([][]int, len(nodes))
+	for u := range dag {
+		for _, v := range dag[u] {
+			preds[v] = append(preds[v], u)
+		}
+	}
+	reorder := func(l []int, neighbors [][]int) {
+		key := make(map[int]float64, len(l))
+		for _, i := range l {
+			if len(neighbors[i]) == 0 {
+				key[i] = float64(nodes[i].row)
+				continue
+			}
+			sum := 0.0
+			for _, j := range neighbors[i] {
+				sum += float64(nodes[j].row)
+			}
+			key[i] = sum / float64(len(neighbors[i]))
+		}
+		sort.SliceStable(l, func(a, b int) bool { return key[l[a]] < key[l[b]] })
+		for r, i := range l {
+			nodes[i].row = r
+		}
+	}
+	for iter := 0; iter < 4; iter++ {
+		for li := 1; li < len(layers); li++ {
+			reorder(layers[li], preds)
+		}
+		for li := len(layers) - 2; li >= 0; li-- {
+			reorder(layers[li], dag)
+		}
+	}
+
+	// Clusters: within each layer, nodes are sorted by cluster, keeping the
+	// barycenter order inside it; a cluster is as tall as its largest layer
+	hasPkg, hasType := groupFlags(group)
+	levels := 0
+	if hasPkg {
+		levels++
+	}
+	if hasType {
+		levels++
+	}
+	type clusterKey struct{ pkg, typ string }
+	keyOf := func(i int) clusterKey {
+		var k clusterKey
+		if hasPkg {
+			k.pkg = nodes[i].node.PackagePath
+		}
+		if hasType {
+			k.typ = nodeTypeGroup(&nodes[i].node)
+		}
+		return k
+	}
+	keyLess := func(a, b clusterKey) bool {
+		if a.pkg != b.pkg {
+			return a.pkg < b.pkg
+		}
+		return a.typ < b.typ
+	}
+	heights := make(map[clusterKey]int)
+	members := make(map[clusterKey][]int)
+	if levels > 0 {
+		for _, l := range layers {
+			sort.SliceStable(l, func(a, b int) bool { return keyLess(keyOf(l[a]), keyOf(l[b])) })
+			count := make(map[clusterKey]int)
+			for _, i := range l {
+				k := keyOf(i)
+				nodes[i].row = count[k] // row within the cluster
+				count[k]++
+				if count[k] > heights[k] {
+					heights[k] = count[k]
+				}
+				members[k] = append(members[k], i)
+			}
+		}
+	}
+	keys := make([]clusterKey, 0, len(heights))
+	for k := range heights {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool { return keyLess(keys[a], keys[b]) })
+
+	// Coordinates: one column per layer, rows centered vertically or
+	// stacked in the cluster bands, outer clusters before their inner ones
+	maxRows := 0
+	for _, l := range layers {
+		if len(l) > maxRows {
+			maxRows = len(l)
+		}
+	}
+	rowHeight := float64(svgNodeHeight + svgRowGap)
+	graphHeight := float64(svgMargin) + float64(maxRows)*rowHeight
+	var clusters []*svgCluster
+	bandTop := make(map[clusterKey]float64, len(keys))
+	if levels > 0 {
+		y := float64(svgMargin)
+		var outer *svgCluster
+		for idx, k := range keys {
+			if levels == 2 && (idx == 0 || keys[idx-1].pkg != k.pkg) {
+				outer = &svgCluster{label: "pkg:" + k.pkg, top: y}
+				clusters = append(clusters, outer)
+				y += svgClusterTop
+			}
+			c := &svgCluster{label: "pkg:" + k.pkg, depth: levels - 1, members: members[k], top: y}
+			if hasType {
+				c.label = "type:" + k.typ
+			}
+			clusters = append(clusters, c)
+			y += svgClusterTop
+			bandTop[k] = y
+			y += float64(heights[k]) * rowHeight
+			c.bottom = y
+			y += svgClusterPad
+			if levels == 2 {
+				outer.members = append(outer.members, c.members...)
+				if idx == len(keys)-1 || keys[idx+1].pkg != k.pkg {
+					outer.bottom = y
+					y += svgClusterPad
+				}
+			}
+		}
+		graphHeight = y
+	}
+	x := float64(svgMargin + levels*svgClusterPad)
+	for _, l := range layers {
+		colWidth := 0.0
+		for _, i := range l {
+			chars := len(nodes[i].node.Func)
+			if len(nodes[i].sub) > chars {
+				chars = len(nodes[i].sub)
+			}
+			nodes[i].w = float64(chars*svgCharWidth + 2*svgNodePadX)
+			if nodes[i].w > colWidth {
+				colWidth = nodes[i].w
+			}
+		}
+		offset := float64(maxRows-len(l)) * rowHeight / 2
+		for _, i := range l {
+			nodes[i].x = x + (colWidth-nodes[i].w)/2
+			if levels > 0 {
+				nodes[i].y = bandTop[keyOf(i)] + float64(nodes[i].row)*rowHeight
+			} else {
+				nodes[i].y = float64(svgMargin) + offset + float64(nodes[i].row)*rowHeight
+			}
+		}
+		x += colWidth + svgLayerGap
+	}
+
+	// Package colors and legend
+	var pkgs []string
+	pkgColor := make(map[string]string)
+	for _, n := range nodes {
+		if _, ok := pkgColor[n.node.PackagePath]; !ok {
+			pkgColor[n.node.PackagePath] = ""
+			pkgs = append(pkgs, n.node.PackagePath)
+		}
+	}
+	sort.Strings(pkgs)
+	for i, p := range pkgs {
+		pkgColor[p] = svgPalette[i%len(svgPalette)]
+	}
+
+	width := x - svgLayerGap + svgMargin + float64(levels*svgClusterPad)
+	for _, c := range clusters {
+		// Outer clusters are padded once more per inner level
+		pad := float64((levels - c.depth) * svgClusterPad)
+		c.left, c.right = nodes[c.members[0]].x, 0.0
+		for _, i := range c.members {
+			if nodes[i].x < c.left {
+				c.left = nodes[i].x
+			}
+			if nodes[i].x+nodes[i].w > c.right {
+				c.right = nodes[i].x + nodes[i].w
+			}
+		}
+		c.left -= pad
+		c.right += pad
+		if w := c.left + float64(len(c.label)*svgCharWidth+svgClusterPad); w > c.right {
+			c.right = w
+		}
+		if c.right+svgMargin > width {
+			width = c.right + svgMargin
+		}
+	}
+	legendWidth := 0.0
+	for _, p := range pkgs {
+		if w := float64(len(p)*svgCharWidth + 40); w > legendWidth {
+			legendWidth = w
+		}
+	}
+	if legendWidth+2*svgMargin > width {
+		width = legendWidth + 2*svgMargin
+	}
+	height := graphHeight + float64(len(pkgs)*svgLegendRow) + 2*svgMargin
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="monospace">`+"\n", width, height, width, height))
+	sb.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="#555"/></marker>`)
+	for _, e := range edges {
+		if cycle[e] {
+			sb.WriteString(`<marker id="cycle-arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="#d62728"/></marker>`)
+			break
+		}
+	}
+	sb.WriteString("</defs>\n")
+	sb.WriteString(`<rect width="100%" height="100%" fill="white"/>` + "\n")
+
+	// Clusters below everything, outer ones first
+	for _, c := range clusters {
+		sb.WriteString(fmt.Sprintf(`<g class="cluster"><title>%s</title><rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="6" fill="none" stroke="#adb5bd"/><text x="%.1f" y="%.1f" font-size="11" fill="#495057">%s</text></g>`+"\n",
+			html.EscapeString(c.label), c.left, c.top, c.right-c.left, c.bottom-c.top, c.left+4, c.top+13, html.EscapeString(c.label)))
+	}
+
+	// Edges first so that nodes are drawn on top
+	half := float64(svgNodeHeight) / 2
+	for _, e := range edges {
+		from, to := nodes[e.from], nodes[e.to]
+		var path string
+		switch {
+		case e.from == e.to:
+			sx, sy := from.x+from.w, from.y+half
+			path = fmt.Sprintf("M%.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f", sx, sy-8, sx+30, sy-30, sx+30, sy+30, sx, sy+8)
+		case to.layer > from.layer:
+			sx, sy := from.x+from.w, from.y+half
+			tx, ty := to.x, to.y+half
+			mx := (sx + tx) / 2
+			path = fmt.Sprintf("M%.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f", sx, sy, mx, sy, mx, ty, tx, ty)
+		default:
+			// Back edge or same layer: loop below the nodes
+			sx, sy := from.x+from.w/2, from.y+float64(svgNodeHeight)
+			tx, ty := to.x+to.w/2, to.y+float64(svgNodeHeight)
+			dip := rowHeight
+			path = fmt.Sprintf("M%.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f", sx, sy, sx, sy+dip, tx, ty+dip, tx, ty)
+		}
+		kind := kinds[e]
+		style := `stroke-width="1.2"`
+		switch {
+		case kind == EdgeKindGo:
+			style = `stroke-width="2.5"`
+		case dynamicKind(kind):
+			style = `stroke-width="1.2" stroke-dasharray="4 3"`
+		}
+		title := from.node.ID + " -> " + to.node.ID
+		if kind != EdgeKindStatic {
+			title += " (" + kind + ")"
+		}
+		stroke, marker := "#555", "arrow"
+		if cycle[e] {
+			// Same red as the Mermaid linkStyle of findCycles
+			stroke, marker = "#d62728", "cycle-arrow"
+			style = strings.Replace(style, `stroke-width="1.2"`, `stroke-width="3"`, 1)
+		}
+		sb.WriteString(fmt.Sprintf(`<path d="%s" fill="none" stroke="%s" %s marker-end="url(#%s)"><title>%s</title></path>`+"\n",
+			path, stroke, style, marker, html.EscapeString(title)))
+	}
+
+	for _, n := range nodes {
+		title := n.node.ID + "\n" + n.sub
+		stroke := `stroke="#333"`
+		if n.node.MoreCallees > 0 || n.node.MoreCallers > 0 {
+			// Cut off by the depth limit
+			title += "\n" + frontierLabel(&n.node)
+			stroke = `stroke="#d9480f" stroke-dasharray="5 5"`
+		}
+		sb.WriteString(fmt.Sprintf(`<g><title>%s</title>`, html.EscapeString(title)))
+		sb.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%d" rx="4" fill="%s" %s/>`,
+			n.x, n.y, n.w, svgNodeHeight, pkgColor[n.node.PackagePath], stroke))
+		sb.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" font-size="12" text-anchor="middle">%s</text>`,
+			n.x+n.w/2, n.y+15, html.EscapeString(n.node.Func)))
+		sb.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" font-size="10" fill="#555" text-anchor="middle">%s</text>`,
+			n.x+n.w/2, n.y+29, html.EscapeString(n.sub)))
+		sb.WriteString("</g>\n")
+	}
+
+	y := graphHeight + float64(svgMargin)
+	for _, p := range pkgs {
+		sb.WriteString(fmt.Sprintf(`<rect x="%d" y="%.1f" width="12" height="12" fill="%s" stroke="#333"/><text x="%d" y="%.1f" font-size="11">pkg:%s</text>`+"\n",
+			svgMargin, y, pkgColor[p], svgMargin+18, y+10, html.EscapeString(p)))
+		y += svgLegendRow
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// defaultFindSymbolMaxResults bounds the findSymbol candidate list
+const defaultFindSymbolMaxResults = 20
+
+// MCPFindSymbolRequest represents the input parameters for the findSymbol tool via MCP.
+// Analysis parameters are shared with callHierarchy.
+type MCPFindSymbolRequest struct {
+	MCPCallgraphRequest
+	Query      string `json:"query"`
+	MaxResults int    `json:"max_results,omitempty"`
+}
+
+// MCPFindSymbolResponse represents the output of the findSymbol tool via MCP
+type MCPFindSymbolResponse struct {
+	Algorithm  string      `json:"algorithm"`
+	Query      string      `json:"query"`
+	Symbols    []MCPSymbol `json:"symbols"`
+	Total      int         `json:"total"`
+	DurationMs int         `json:"durationMs"`
+	Warnings   []string    `json:"warnings,omitempty"`
+}
+
+// MCPSymbol is a function candidate; Name is accepted as-is by symbol, from and to
+type MCPSymbol struct {
+	Name         string  `json:"name"`
+	Func         string  `json:"func"`
+	PackagePath  string  `json:"packagePath"`
+	ReceiverType *string `json:"receiverType,omitempty"`
+	Signature    string  `json:"signature"`
+	File         string  `json:"file"`
+	Line         int     `json:"line"`
+	Exported     bool    `json:"exported"`
+	Score        int     `json:"score"`
+}
+
+// HandleFindSymbolRequest processes the MCP findSymbol request
+func HandleFindSymbolRequest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	start := time.Now()
+
+	var req MCPFindSymbolRequest
+	if err := decodeArguments(request, &req); err != nil {
+		return errorResult("%v", err), nil
+	}
+
+	// Validate required parameters
+	if len(req.ModuleArgs) == 0 {
+		return errorResult("Error: moduleArgs is required"), nil
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return errorResult("Error: query is required"), nil
+	}
+	if req.OutputFormat != "" && req.OutputFormat != "text" && req.OutputFormat != OutputFormatJSON {
+		return errorResult("Error: invalid output_format %q (expected text or json)", req.OutputFormat), nil
+	}
+	if req.MaxResults <= 0 {
+		req.MaxResults = defaultFindSymbolMaxResults
+	}
+	req.applyDefaults(request)
+
+	ctx = withProgress(ctx, request)
+	ctx, cancel := withRequestTimeout(ctx, req.MCPCallgraphRequest)
+	defer cancel()
+
+	a, err := newRequestAnalysis(ctx, req.MCPCallgraphRequest)
+	if err != nil {
+		return errorResult("%v", err), nil
+	}
+
+	matches := a.searchSymbols(strings.TrimSpace(req.Query))
+	if err := checkContext(ctx, phaseFilter); err != nil {
+		return errorResult("%v", err), nil
+	}
+	progressf(ctx, stepRender, "%d symbol(s) match; rendering", len(matches))
+	resp := &MCPFindSymbolResponse{
+		Algorithm: req.Algo,
+		Query:     req.Query,
+		Symbols:   make([]MCPSymbol, 0, len(matches)),
+		Total:     len(matches),
+		Warnings:  a.warnings,
+	}
+	for i, m := range matches {
+		if i >= req.MaxResults {
+			break
+		}
+		resp.Symbols = append(resp.Symbols, a.newSymbol(m.node.Func, m.score))
+	}
+	resp.DurationMs = int(time.Since(start).Milliseconds())
+
+	if req.OutputFormat == OutputFormatJSON {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return errorResult("Error rendering output: %v", err), nil
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(string(data))}}, nil
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(formatSymbols(resp))}}, nil
+}
+
+// symbolMatch is a callgraph node scored against a query
+type symbolMatch struct {
+	node  *callgraph.Node
+	score int
+}
+
+// symbolCandidates returns the named functions of the callgraph that pass the
+// nostd, limit_prefix and ignore filters, ordered by fully qualified name
+func (a *analysis) symbolCandidates() []*callgraph.Node {
+	var nodes []*callgraph.Node
+	for fn, n := range a.callgraph.Nodes {
+		if fn == nil || funcPkg(fn) == nil || isSyntheticFunc(fn) {
+			continue
+		}
+		pkgPath := funcPkg(fn).Path()
+		if a.opts.nostd && (isStdPkgPath(pkgPath) || isInternalPkg(pkgPath)) {
+			continue
+		}
+		if len(a.opts.include) > 0 && !hasAnyPrefix(pkgPath, a.opts.include) {
+			continue
+		}
+		if containsAny(pkgPath, a.opts.ignore) {
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Func.String() < nodes[j].Func.String() })
+	return nodes
+}
+
+// searchSymbols ranks the candidate functions by fuzzy score, best first
+func (a *analysis) searchSymbols(query string) []symbolMatch {
+	var matches []symbolMatch
+	for _, n := range a.symbolCandidates() {
+		if score := fuzzySymbolScore(query, n.Func); score > 0 {
+			matches = append(matches, symbolMatch{node: n, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	return matches
+}
+
+// findSymbolNode resolves a symbol to exactly one callgraph node. The most
+// specific spelling wins (import path, package name, receiver type, bare name);
+// several functions matching at the same level are reported as ambiguous.
+func (a *analysis) findSymbolNode(symbol string) (*callgraph.Node, error) {
+	nodes := make([]*callgraph.Node, 0, len(a.callgraph.Nodes))
+	for fn, n := range a.callgraph.Nodes {
+		if fn != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	matches := bestSymbolMatches(symbol, nodes)
+
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		if err := a.promotedMethodError(symbol); err != nil {
+			return nil, err
+		}
+		var hints []string
+		for _, m := range a.searchSymbols(symbol) {
+			if len(hints) == 3 {
+				break
+			}
+			hints = append(hints, m.node.Func.String())
+		}
+		if len(hints) > 0 {
+			return nil, fmt.Errorf("symbol not found: %s (did you mean: %s?)", symbol, strings.Join(hints, ", "))
+		}
+		return nil, fmt.Errorf("symbol not found: %s", symbol)
+	default:
+		return nil, a.ambiguousError(fmt.Sprintf("symbol %q", symbol), matches)
+	}
+}
+
+// bestSymbolMatches returns the nodes that symbol names at the most specific
+// level. When several do, the one whose fully qualified name is symbol wins;
+// otherwise they are all returned, standard library namesakes and generic
+// bodies included, so that the ambiguity is reported rather than guessed.
+func bestSymbolMatches(symbol string, nodes []*callgraph.Node) []*callgraph.Node {
+	var matches []*callgraph.Node
+	best := 0
+	for _, n := range nodes {
+		tier := symbolMatchTier(symbol, n.Func)
+		if tier == 0 || tier < best {
+			continue
+		}
+		if tier > best {
+			best = tier
+			matches = matches[:0]
+		}
+		matches = append(matches, n)
+	}
+	if len(matches) > 1 {
+		for _, n := range matches {
+			if n.Func.String() == symbol {
+				return []*callgraph.Node{n}
+			}
+		}
+	}
+	return matches
+}
+
+// ambiguousError lists the functions matched by what, so that one can be picked by name
+func (a *analysis) ambiguousError(what string, matches []*callgraph.Node) error {
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Func.String() < matches[j].Func.String() })
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("ambiguous %s matches %d functions; use one of these fully qualified names:", what, len(matches)))
+	for _, n := range matches {
+		s := a.newSymbol(n.Func, 0)
+		sb.WriteString(fmt.Sprintf("\n  %s  %s  [%s:%d]", s.Name, s.Signature, s.File, s.Line))
+	}
+	return fmt.Errorf("%s", sb.String())
+}
+
+// Match levels of symbolMatchTier, most specific first
+const (
+	tierQualified = 4 // import path: "github.com/org/repo/pkg.(*Server).Handle"
+	tierPackage   = 3 // package name: "pkg.Server.Handle"
+	tierReceiver  = 2 // receiver type: "(*Server).Handle", "Server.Handle"
+	tierName      = 1 // bare name: "Handle"
+)
+
+// isInstantiated reports whether n is not the body of a generic function
+func isInstantiated(n *callgraph.Node) bool {
+	return n.Func.TypeParams().Len() == 0 || len(n.Func.TypeArgs()) > 0
+}
+
+// preferMatches narrows ambiguous matches to those satisfying keep, if any do
+func preferMatches(matches []*callgraph.Node, keep func(*callgraph.Node) bool) []*callgraph.Node {
+	if len(matches) < 2 {
+		return matches
+	}
+	var kept []*callgraph.Node
+	for _, n := range matches {
+		if keep(n) {
+			kept = append(kept, n)
+		}
+	}
+	if len(kept) == 0 {
+		return matches
+	}
+	return kept
+}
+
+// symbolMatchTier reports how specifically symbol names fn (0: no match).
+// Parentheses and pointer stars are ignored, so "(*Server).Handle",
+// "Server.Handle" and "pkg.(*Server).Handle" all name the same method.
+// Closures are named after their parent ("main.main$1", "Server.Handle$1")
+// and generic instantiations match with or without type arguments.
+func symbolMatchTier(symbol string, fn *ssa.Function) int {
+	symbol = normalizeSymbol(symbol)
+	best := 0
+	for spelling, tier := range symbolSpellings(fn) {
+		if tier > best && spelling == symbol {
+			best = tier
+		}
+	}
+	return best
+}
+
+// normalizeSymbol drops the receiver punctuation of a symbol
+func normalizeSymbol(symbol string) string {
+	return strings.NewReplacer("(", "", ")", "", "*", "").Replace(strings.TrimSpace(symbol))
+}
+
+// symbolSpellings returns the normalized spellings that address fn, with their tier
+func symbolSpellings(fn *ssa.Function) map[string]int {
+	spellings := map[string]int{normalizeSymbol(fn.String()): tierQualified}
+	add := func(spelling string, tier int) {
+		spelling = normalizeSymbol(spelling)
+		if spellings[spelling] < tier {
+			spellings[spelling] = tier
+		}
+	}
+
+	names := []string{fn.Name()}
+	if origin := fn.Origin(); origin != nil && origin.Name() != fn.Name() {
+		// Generic instantiation, e.g. "Map[int string]" is also "Map"
+		names = append(names, origin.Name())
+	}
+
+	// Methods and closures declared inside methods are qualified by the receiver type
+	var recvs []string
+	top := fn
+	for top.Parent() != nil {
+		top = top.Parent()
+	}
+	if recv := top.Signature.Recv(); recv != nil {
+		t := recv.Type()
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if named, ok := t.(*types.Named); ok {
+			withArgs := types.TypeString(named, func(*types.Package) string { return "" })
+			recvs = append(recvs, withArgs)
+			if base := named.Obj().Name(); base != withArgs {
+				recvs = append(recvs, base)
+			}
+		}
+	}
+
+	var pkgPath, pkgName string
+	if pkg := funcPkg(fn); pkg != nil {
+		pkgPath, pkgName = pkg.Path(), pkg.Name()
+	}
+	for _, name := range names {
+		add(name, tierName)
+		qualified := []string{name}
+		for _, r := range recvs {
+			add(r+"."+name, tierReceiver)
+			qualified = append(qualified, r+"."+name)
+		}
+		if pkgPath == "" {
+			continue
+		}
+		for _, q := range qualified {
+			add(pkgPath+"."+q, tierQualified)
+			add(pkgName+"."+q, tierPackage)
+		}
+	}
+	return spellings
+}
+
+// promotedMethodError explains a "Type.Method" symbol whose method is promoted
+// through an embedded field: the promoted method is a synthetic wrapper that is
+// not part of the callgraph, so it must be addressed by its declaring type.
+func (a *analysis) promotedMethodError(symbol string) error {
+	parts := strings.Split(normalizeSymbol(symbol), ".")
+	if len(parts) < 2 {
+		return nil
+	}
+	method, typeName := parts[len(parts)-1], parts[len(parts)-2]
+	qualifier := strings.Join(parts[:len(parts)-2], ".")
+	for _, p := range a.pkgs {
+		if p == nil || p.Pkg == nil {
+			continue
+		}
+		if qualifier != "" && qualifier != p.Pkg.Name() && qualifier != p.Pkg.Path() {
+			continue
+		}
+		obj, ok := p.Pkg.Scope().Lookup(typeName).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		mset := types.NewMethodSet(types.NewPointer(obj.Type()))
+		for i := 0; i < mset.Len(); i++ {
+			sel := mset.At(i)
+			if sel.Obj().Name() != method || len(sel.Index()) < 2 {
+				continue
+			}
+			return fmt.Errorf("method %s.%s is promoted from an embedded field and has no function of its own; use the declaring method %s",
+				typeName, method, sel.Obj().(*types.Func).FullName())
+		}
+	}
+	return nil
+}
+
+// fuzzySymbolScore scores fn against a case-insensitive query: exact spellings
+// first, then name prefix, name substring, qualified-name substring and finally
+// an in-order subsequence of the qualified name. 0 means no match.
+func fuzzySymbolScore(query string, fn *ssa.Function) int {
+	if tier := symbolMatchTier(query, fn); tier > 0 {
+		return 1000 + tier*100
+	}
+	q := strings.ToLower(query)
+	name := strings.ToLower(fn.Name())
+	qualified := strings.ToLower(fn.String())
+	switch {
+	case name == q:
+		return 900
+	case strings.HasPrefix(name, q):
+		return 800 - clampScore(len(name)-len(q))
+	case strings.Contains(name, q):
+		return 700 - clampScore(strings.Index(name, q))
+	case strings.Contains(qualified, q):
+		return 600 - clampScore(len(qualified)-len(q))
+	}
+	return subsequenceScore(q, qualified)
+}
+
+// subsequenceScore rewards runs of consecutive characters when q is a
+// subsequence of s; 0 if it is not
+func subsequenceScore(q, s string) int {
+	if q == "" {
+		return 0
+	}
+	score, run, qi := 100, 0, 0
+	for si := 0; si < len(s) && qi < len(q); si++ {
+		if s[si] == q[qi] {
+			qi++
+			run++
+			score += run * 5
+		} else {
+			run = 0
+		}
+	}
+	if qi < len(q) {
+		return 0
+	}
+	score -= clampScore(len(s) - len(q))
+	if score < 1 {
+		score = 1
+	}
+	if score > 599 {
+		score = 599
+	}
+	return score
+}
+
+// clampScore keeps length penalties from crossing into the next score band
+func clampScore(n int) int {
+	if n > 99 {
+		return 99
+	}
+	return n
+}
+
+// newSymbol describes fn for findSymbol output and disambiguation errors
+func (a *analysis) newSymbol(fn *ssa.Function, score int) MCPSymbol {
+	pos := a.prog.Fset.Position(fn.Pos())
+	var qualifier types.Qualifier
+	var pkgPath string
+	if pkg := funcPkg(fn); pkg != nil {
+		qualifier = types.RelativeTo(pkg)
+		pkgPath = pkg.Path()
+	}
+	var receiverType *string
+	if recv := fn.Signature.Recv(); recv != nil {
+		r := types.TypeString(recv.Type(), qualifier)
+		receiverType = &r
+	}
+	file := ""
+	if pos.Filename != "" {
+		file = filepath.Base(pos.Filename)
+	}
+	return MCPSymbol{
+		Name:         fn.String(),
+		Func:         fn.Name(),
+		PackagePath:  pkgPath,
+		ReceiverType: receiverType,
+		Signature:    types.TypeString(fn.Signature, qualifier),
+		File:         file,
+		Line:         pos.Line,
+		Exported:     fn.Object() != nil && fn.Object().Exported(),
+		Score:        score,
+	}
+}
+
+// formatSymbols renders the candidates as readable text, best match first
+func formatSymbols(resp *MCPFindSymbolResponse) string {
+	var sb strings.Builder
+	for _, w := range resp.Warnings {
+		sb.WriteString("warning: " + w + "\n")
+	}
+	if len(resp.Symbols) == 0 {
+		sb.WriteString(fmt.Sprintf("No symbol matches %q (algo: %s)\n", resp.Query, resp.Algorithm))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("Found %d symbol(s) matching %q (algo: %s)", resp.Total, resp.Query, resp.Algorithm))
+	if resp.Total > len(resp.Symbols) {
+		sb.WriteString(fmt.Sprintf(", showing the best %d", len(resp.Symbols)))
+	}
+	sb.WriteString("\n\n")
+	for i, s := range resp.Symbols {
+		sb.WriteString(fmt.Sprintf("%d. %s\n   %s  [%s:%d]\n", i+1, s.Name, s.Signature, s.File, s.Line))
+	}
+	return sb.String()
+}
+
+// hasAnyPrefix reports whether s starts with one of prefixes
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny reports whether s contains one of subs
+func containsAny(s string, subs []string) bool {
+	for _, p := range subs {
+		if strings.Contains(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// DefaultHTTPPath is the streamable HTTP endpoint when MCP_HTTP_PATH is not set
+const DefaultHTTPPath = "/mcp"
+
+// HTTPEndpointPath normalizes the MCP_HTTP_PATH endpoint: a missing leading
+// slash is added and a trailing one dropped. Paths the mux cannot route
+// (wildcards, methods, queries) are rejected.
+func HTTPEndpointPath(p string) (string, error) {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return DefaultHTTPPath, nil
+	}
+	if strings.ContainsAny(p, " \t{}?#") {
+		return "", fmt.Errorf("invalid HTTP path %q: expected a plain path such as /team/mcp", p)
+	}
+	return path.Clean("/" + p), nil
+}
+
+// NewHTTPHandler serves mcpHandler on endpointPath only, every other path
+// getting 404, behind RequireToken with tokens
+func NewHTTPHandler(mcpHandler http.Handler, endpointPath string, tokens []string) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(endpointPath, mcpHandler)
+	return RequireToken(tokens, mux)
+}
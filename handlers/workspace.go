@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/tools/go/packages"
+)
+
+// RootsResourceURI is the MCP resource listing the allowed workspace roots
+const RootsResourceURI = "callgraph://roots"
+
+// workspaceRoots holds the directories dir and moduleArgs must resolve
+// inside, with symlinks resolved; empty means unrestricted
+var workspaceRoots struct {
+	mu   sync.RWMutex
+	dirs []string
+}
+
+// SetAllowedRoots restricts analyses to the given directories (nil lifts the
+// restriction). Each root must exist; symlinks in it are resolved.
+func SetAllowedRoots(roots []string) error {
+	var dirs []string
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		dir, err := resolvePath(root)
+		if err != nil {
+			return fmt.Errorf("invalid root %q: %v", root, err)
+		}
+		dirs = append(dirs, dir)
+	}
+	workspaceRoots.mu.Lock()
+	defer workspaceRoots.mu.Unlock()
+	workspaceRoots.dirs = dirs
+	return nil
+}
+
+// AllowedRoots returns the resolved allowed roots, or nil when unrestricted
+func AllowedRoots() []string {
+	workspaceRoots.mu.RLock()
+	defer workspaceRoots.mu.RUnlock()
+	return append([]string(nil), workspaceRoots.dirs...)
+}
+
+// checkWorkspace verifies that dir and the filesystem patterns of moduleArgs
+// resolve inside an allowed root. Import path patterns are resolved by the
+// module of dir: checkLoadedPackages checks the packages they match.
+func checkWorkspace(dir string, moduleArgs []string) error {
+	roots := AllowedRoots()
+	if len(roots) == 0 {
+		return nil
+	}
+	base := dir
+	if base == "" {
+		// packages.Load runs in the server's working directory
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("Error: cannot resolve the working directory: %v", err)
+		}
+		base = wd
+	}
+	if err := checkInsideRoots("dir", dir, base, roots); err != nil {
+		return err
+	}
+	for _, arg := range moduleArgs {
+		p, ok := patternPath(arg)
+		if !ok {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(base, p)
+		}
+		if err := checkInsideRoots("moduleArgs", arg, p, roots); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkLoadedPackages verifies that the packages matched by moduleArgs, not
+// their dependencies, lie inside an allowed root. This catches the import path
+// patterns checkWorkspace cannot resolve, such as all, std or a module path.
+func checkLoadedPackages(initial []*packages.Package) error {
+	roots := AllowedRoots()
+	if len(roots) == 0 {
+		return nil
+	}
+	for _, p := range initial {
+		dir := p.Dir
+		if dir == "" && len(p.GoFiles) > 0 {
+			dir = filepath.Dir(p.GoFiles[0])
+		}
+		if dir == "" {
+			continue
+		}
+		resolved, err := resolvePath(dir)
+		if err != nil {
+			return fmt.Errorf("package %s in %s cannot be resolved: %v", p.PkgPath, dir, err)
+		}
+		inside := false
+		for _, root := range roots {
+			if isWithin(root, resolved) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			return fmt.Errorf("package %s matched by moduleArgs is in %s, outside the allowed roots (%s); see resource %s",
+				p.PkgPath, resolved, strings.Join(roots, ", "), RootsResourceURI)
+		}
+	}
+	return nil
+}
+
+// checkInsideRoots resolves path and reports an error naming what when it
+// falls outside every root
+func checkInsideRoots(what, value, path string, roots []string) error {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return fmt.Errorf("Error: %s %q cannot be resolved: %v", what, value, err)
+	}
+	for _, root := range roots {
+		if isWithin(root, resolved) {
+			return nil
+		}
+	}
+	return fmt.Errorf("Error: %s %q resolves to %s, outside the allowed roots (%s); see resource %s",
+		what, value, resolved, strings.Join(roots, ", "), RootsResourceURI)
+}
+
+// resolvePath makes path absolute and resolves its symlinks
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// isWithin reports whether path is root or below it; both must be resolved
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// patternPath returns the directory or file a package pattern names on the
+// filesystem: relative and absolute paths, possibly with a "..." wildcard,
+// and file= queries. Import paths report false.
+func patternPath(pattern string) (string, bool) {
+	if p, ok := strings.CutPrefix(pattern, "file="); ok {
+		return p, true
+	}
+	if !filepath.IsAbs(pattern) && pattern != "." && pattern != ".." &&
+		!strings.HasPrefix(pattern, "./") && !strings.HasPrefix(pattern, "../") {
+		return "", false
+	}
+	if i := strings.Index(pattern, "..."); i >= 0 {
+		// The wildcard only matches below the directory preceding it
+		prefix := pattern[:i]
+		if strings.HasSuffix(prefix, "/") {
+			return prefix, true
+		}
+		return filepath.Dir(prefix), true
+	}
+	return pattern, true
+}
+
+// HandleRootsResource serves the allowed workspace roots as JSON
+func HandleRootsResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	roots := AllowedRoots()
+	data, err := json.Marshal(struct {
+		Restricted bool     `json:"restricted"`
+		Roots      []string `json:"roots"`
+	}{Restricted: len(roots) > 0, Roots: append([]string{}, roots...)})
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      RootsResourceURI,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}}, nil
+}
@@ -2,8 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -17,11 +25,45 @@ func callgraphTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallT
 	return handlers.HandleCallgraphRequest(ctx, request)
 }
 
+// callPathTool finds call paths between two functions
+func callPathTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handlers.HandleCallPathRequest(ctx, request)
+}
+
+// findSymbolTool searches functions by fuzzy name
+func findSymbolTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handlers.HandleFindSymbolRequest(ctx, request)
+}
+
+// deadCodeTool lists functions unreachable from the RTA roots
+func deadCodeTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handlers.HandleDeadCodeRequest(ctx, request)
+}
+
+// findCyclesTool reports recursive and mutually recursive call chains
+func findCyclesTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handlers.HandleFindCyclesRequest(ctx, request)
+}
+
+// metricsTool reports fan-in/fan-out, depth, centrality and package coupling
+func metricsTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return handlers.HandleMetricsRequest(ctx, request)
+}
+
 func main() {
 	// Create a new MCP server
 	mcpServer := server.NewMCPServer(
 		"callgraph-mcp",
 		"1.0.1",
+		// Analysis phases are forwarded as logging notifications once the client sets a level
+		server.WithLogging(),
+		// Analyzed graphs are added as resources; clients are told when the list changes.
+		// Subscriptions are not supported: reads re-filter the cached analysis instead.
+		server.WithResourceCapabilities(false, true),
+		// Prompt and resource template arguments are completed from the cached analysis
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(handlers.CompletionProvider{}),
+		server.WithResourceCompletionProvider(handlers.CompletionProvider{}),
 	)
 
 	// Register the unified callHierarchy tool
@@ -56,18 +98,104 @@ func main() {
 	    },
 	    "symbol": map[string]interface{}{
 	        "type":        "string",
-	        "description": "Function symbol to start traversal from. Supports: function name ('hello'), package.function ('main.main'), or full path ('github.com/user/repo.function')",
+	        "description": "Function symbol to start traversal from. Supports: function name ('hello'), package.function ('main.main'), full path ('github.com/user/repo.function'), methods ('Server.Handle', '(*Server).Handle', 'pkg.Server.Handle'), closures ('main.main$1') or generic instances ('Map', 'Map[int string]'). Ambiguous names return the candidate list; use findSymbol to look names up",
+	    },
+	    "file": map[string]interface{}{
+	        "type":        "string",
+	        "description": "Source file to start traversal from instead of 'symbol' (absolute, relative to dir, or a path suffix like 'pkg/server.go'); requires 'line'",
+	    },
+	    "line": map[string]interface{}{
+	        "type":        "integer",
+	        "description": "1-based line in 'file'; the innermost function enclosing it (including closures and methods) becomes the start symbol",
+	    },
+	    "column": map[string]interface{}{
+	        "type":        "integer",
+	        "description": "Optional 1-based column (byte offset) in 'line' to pick between functions sharing the line",
 	    },
 	    "direction": map[string]interface{}{
 	        "type":        "string",
 	        "enum":        []string{"downstream", "upstream", "both"},
-	        "description": "Traversal direction (default: downstream; only effective when 'symbol' is specified)",
+	        "description": "Traversal direction (default: downstream; only effective when 'symbol' or 'file' is specified)",
 	    },
 	    "max_dep": map[string]interface{}{
             "type":        "integer",
-            "description": "Max traversal depth (0 for unlimited; defaults: 7 when symbol is specified, 4 otherwise)",
+            "description": "Max traversal depth (0 for unlimited; defaults: 7 when symbol is specified, 4 otherwise). Symbol traversals mark nodes cut off at the limit with a '+N more callees/callers' stub",
+            "default":     0,
+        },
+        "edge_kinds": map[string]interface{}{
+            "type":        "array",
+            "items":       map[string]interface{}{"type": "string", "enum": []string{"static", "interface", "closure", "go", "defer"}},
+            "description": "Only keep calls of these kinds: static (direct call), interface (method dispatch), closure (call through a function value), go (goroutine spawn), defer (deferred call); e.g. ['go'] shows only goroutine spawns. Mermaid draws dynamic calls dotted (-.->), go thick (==>) and defer labeled (default: all)",
+            "default":     []string{},
+        },
+        "granularity": map[string]interface{}{
+            "type":        "string",
+            "enum":        []string{"func", "type", "pkg"},
+            "description": "Node granularity: func (one node per function), type (methods collapsed into their receiver type, plain functions into their package) or pkg (one node per package). Collapsed edges are labeled with the number of underlying calls and calls inside a node are dropped; use pkg with max_dep 0 for a cheap architectural overview, then drill into one package with func (default: func)",
+            "default":     "func",
+        },
+        "max_nodes": map[string]interface{}{
+            "type":        "integer",
+            "description": "Output budget in nodes. A larger graph is degraded step by step: least central packages collapsed into single nodes, then leaf functions beyond some depth pruned, then the package-level view; notes tell what was elided and how to request it (default: 0 = unlimited)",
+            "default":     0,
+        },
+        "max_output_bytes": map[string]interface{}{
+            "type":        "integer",
+            "description": "Output budget in bytes of the requested output_format, degraded like max_nodes; keeps a large graph within the model context (default: 0 = unlimited)",
             "default":     0,
         },
+        "call_counts": map[string]interface{}{
+            "type":        "boolean",
+            "description": "Label Mermaid edges that have several call sites with their count (JSON edges always carry count and every site with file, line, column and kind)",
+            "default":     false,
+        },
+        "timeout_ms": map[string]interface{}{
+            "type":        "integer",
+            "description": "Abort the request after this many milliseconds (default: server-wide MCP_TIMEOUT_MS, unlimited if unset); the error names the analysis phase reached",
+        },
+        "max_dep_upstream": map[string]interface{}{
+            "type":        "integer",
+            "description": "Upstream depth limit for symbol traversal, e.g. with direction 'both' (0 for unlimited; defaults to max_dep)",
+        },
+        "max_dep_downstream": map[string]interface{}{
+            "type":        "integer",
+            "description": "Downstream depth limit for symbol traversal, e.g. with direction 'both' (0 for unlimited; defaults to max_dep)",
+        },
+        "output_format": map[string]interface{}{
+            "type":        "string",
+            "enum":        []string{"mermaid", "json", "both", "dot", "svg"},
+            "description": "Output format: Mermaid flowchart text, structured JSON graph (algorithm, applied filters, stats, nodes and edges with file/line), both, Graphviz DOT with pkg/type clusters, or a rendered SVG image with the same clusters (default: mermaid)",
+            "default":     "mermaid",
+        },
+        "algo": map[string]interface{}{
+            "type":        "string",
+            "enum":        []string{"static", "cha", "rta", "vta"},
+            "description": "The algorithm used to construct the call graph, from cheapest to most precise: static (direct calls only, no interface or function-value calls), cha (every method matching an interface call; fast but heavily over-approximates interface-heavy code), rta (only types instantiated in code reachable from the roots; needs main packages or rta_roots), vta (refines the vta_seed graph by tracking which types flow to each interface and function-value call; most precise, several times slower and more memory hungry than its seed) (default: rta)",
+            "default":     "rta",
+        },
+        "vta_seed": map[string]interface{}{
+            "type":        "string",
+            "enum":        []string{"cha", "rta"},
+            "description": "Initial call graph refined by algo vta: cha (whole program, works for libraries) or rta (only code reachable from rta_roots/roots; smaller and faster) (default: cha)",
+            "default":     "cha",
+        },
+        "rta_roots": map[string]interface{}{
+            "type":        "string",
+            "enum":        []string{"auto", "main", "exported", "tests"},
+            "description": "Entry points of the rta algorithm: main packages; exported functions and methods of the analyzed packages (for libraries); Test/Benchmark/Fuzz functions (requires tests); or auto: main packages, falling back to the exported API (and tests) with a warning when there are none (default: auto)",
+            "default":     "auto",
+        },
+        "roots": map[string]interface{}{
+            "type":        "array",
+            "items":       map[string]string{"type": "string"},
+            "description": "Extra rta entry points, as symbols like 'pkg.NewServer' or '(*Server).Serve'; with rta_roots auto they replace the exported-API fallback",
+            "default":     []string{},
+        },
+        "refresh": map[string]interface{}{
+            "type":        "boolean",
+            "description": "Force rebuilding the analysis instead of reusing the cached program (the cache is in memory only and starts empty after a restart; it is invalidated automatically when go.mod/go.sum or source files change)",
+            "default":     false,
+        },
     }
     internalProps := map[string]interface{}{
         "focus": map[string]interface{}{
@@ -95,12 +223,6 @@ func main() {
             "description": "Include test code",
             "default":     false,
         },
-        "algo": map[string]interface{}{
-            "type":        "string",
-            "enum":        []string{"static", "cha", "rta"},
-            "description": "The algorithm used to construct the call graph (default: rta)",
-            "default":     "rta",
-        },
         "tags": map[string]interface{}{
             "type":        "array",
             "items":       map[string]string{"type": "string"},
@@ -128,11 +250,18 @@ func main() {
 		"\nExample (through specific function - add these two lines):" +
 		"\n  \"symbol\": \"main.main\"," +
 		"\n  \"direction\": \"downstream\"" +
+		"\nExample (from an editor position - instead of symbol):" +
+		"\n  \"file\": \"internal/server/handler.go\"," +
+		"\n  \"line\": 42" +
 		"\n\nWhat this tool is good for:" +
 		"\n- Analyze project structure and module boundaries" +
 		"\n- Inspect function call chains (downstream/upstream)" +
 		"\n- Understand cross-package dependencies and hot paths" +
-		"\n- Quickly scope analysis with limit_keyword/limit_prefix (suggest to set one of them)" ,
+		"\n- Quickly scope analysis with limit_keyword/limit_prefix (suggest to set one of them)" +
+		"\n\nChoosing algo: rta (default) suits programs with main packages; for libraries keep rta with rta_roots, " +
+		"and switch to vta when interface or callback dispatch matters (e.g. plugin registries), at a higher analysis cost. " +
+		"Each JSON edge carries the algorithm that resolved it, so runs can be compared. " +
+		"The output links the graph as resources to read again later; they cannot be subscribed to, re-read them to see code changes",
 		InputSchema: mcp.ToolInputSchema{
 			Type:       "object",
 			Properties: filtered,
@@ -140,6 +269,297 @@ func main() {
 		},
 	}, callgraphTool)
 
+	// Register the callPath tool (shares analysis parameters and filters with callHierarchy)
+	callPathProps := map[string]interface{}{
+		"moduleArgs":    externalProps["moduleArgs"],
+		"dir":           externalProps["dir"],
+		"limit_keyword": externalProps["limit_keyword"],
+		"limit_prefix":  externalProps["limit_prefix"],
+		"ignore":        externalProps["ignore"],
+		"edge_kinds":    externalProps["edge_kinds"],
+		"nostd":         internalProps["nostd"],
+		"nointer":       internalProps["nointer"],
+		"refresh":       externalProps["refresh"],
+		"algo":          externalProps["algo"],
+		"vta_seed":      externalProps["vta_seed"],
+		"rta_roots":     externalProps["rta_roots"],
+		"roots":         externalProps["roots"],
+		"timeout_ms":    externalProps["timeout_ms"],
+		"from": map[string]interface{}{
+			"type":        "string",
+			"description": "Function symbol where the call chain starts (same syntax as callHierarchy 'symbol')",
+		},
+		"to": map[string]interface{}{
+			"type":        "string",
+			"description": "Function symbol where the call chain ends",
+		},
+		"mode": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"shortest", "k_shortest", "all"},
+			"description": "shortest: one shortest path; k_shortest: the k shortest simple paths; all: all simple paths up to max_length (default: shortest)",
+			"default":     "shortest",
+		},
+		"k": map[string]interface{}{
+			"type":        "integer",
+			"description": "Number of paths for k_shortest (default 3), or the maximum number of paths for all (default 100)",
+		},
+		"max_length": map[string]interface{}{
+			"type":        "integer",
+			"description": "Maximum number of calls per path (default: unlimited for shortest, 10 otherwise)",
+		},
+		"output_format": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"text", "json"},
+			"description": "Readable path list or structured JSON (default: text)",
+			"default":     "text",
+		},
+	}
+	mcpServer.AddTool(mcp.Tool{
+		Name:        "callPath",
+		Description: "Find how one Go function ends up calling another: returns the shortest call path (or the k shortest / all simple paths) with the call-site file:line of every hop" +
+		"\nExample:\n{" +
+		"\n  \"dir\": \"/path/to/project\"," +
+		"\n  \"moduleArgs\": [\"./...\"]," +
+		"\n  \"from\": \"main.main\"," +
+		"\n  \"to\": \"db.Query\"\n}" +
+		"\nFilters (nostd/nointer/limit_prefix/limit_keyword/ignore) behave as in callHierarchy; set nointer=false to follow unexported functions",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: callPathProps,
+			Required:   []string{"moduleArgs", "from", "to"},
+		},
+	}, callPathTool)
+
+	// Register the findSymbol tool (fuzzy lookup of names accepted by symbol/from/to)
+	findSymbolProps := map[string]interface{}{
+		"moduleArgs":   externalProps["moduleArgs"],
+		"dir":          externalProps["dir"],
+		"limit_prefix": externalProps["limit_prefix"],
+		"ignore":       externalProps["ignore"],
+		"nostd":        internalProps["nostd"],
+		"refresh":      externalProps["refresh"],
+		"algo":         externalProps["algo"],
+		"vta_seed":     externalProps["vta_seed"],
+		"rta_roots":    externalProps["rta_roots"],
+		"roots":        externalProps["roots"],
+		"timeout_ms":   externalProps["timeout_ms"],
+		"query": map[string]interface{}{
+			"type":        "string",
+			"description": "Function name or fragment to search for (case-insensitive; e.g. 'handle', 'Server.Hand', 'srvhdl')",
+		},
+		"max_results": map[string]interface{}{
+			"type":        "integer",
+			"description": "Maximum number of candidates to return (default 20)",
+			"default":     20,
+		},
+		"output_format": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"text", "json"},
+			"description": "Readable candidate list or structured JSON (default: text)",
+			"default":     "text",
+		},
+	}
+	mcpServer.AddTool(mcp.Tool{
+		Name:        "findSymbol",
+		Description: "Search the functions of a Go program by fuzzy name. Returns ranked candidates with fully qualified name, receiver, signature and file:line; " +
+		"the fully qualified name can be passed unchanged as callHierarchy 'symbol' or callPath 'from'/'to'" +
+		"\nExample:\n{" +
+		"\n  \"dir\": \"/path/to/project\"," +
+		"\n  \"moduleArgs\": [\"./...\"]," +
+		"\n  \"query\": \"handle\"\n}",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: findSymbolProps,
+			Required:   []string{"moduleArgs", "query"},
+		},
+	}, findSymbolTool)
+
+	// Register the deadCode tool (functions RTA cannot reach from the roots)
+	deadCodeProps := map[string]interface{}{
+		"moduleArgs":   externalProps["moduleArgs"],
+		"dir":          externalProps["dir"],
+		"limit_prefix": externalProps["limit_prefix"],
+		"ignore":       externalProps["ignore"],
+		"tests":        internalProps["tests"],
+		"rta_roots":    externalProps["rta_roots"],
+		"roots":        externalProps["roots"],
+		"refresh":      externalProps["refresh"],
+		"timeout_ms":   externalProps["timeout_ms"],
+		"output_format": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"text", "json"},
+			"description": "Readable report grouped by package or structured JSON (default: text)",
+			"default":     "text",
+		},
+	}
+	mcpServer.AddTool(mcp.Tool{
+		Name:        "deadCode",
+		Description: "List the functions and methods of the analyzed Go packages that are unreachable from the entry points (RTA), grouped by package with file:line and exported status. " +
+		"Roots are the main packages by default; use rta_roots 'exported' for a library's API, 'tests' with tests=true for test entry points, or list extra roots" +
+		"\nExample:\n{" +
+		"\n  \"dir\": \"/path/to/project\"," +
+		"\n  \"moduleArgs\": [\"./...\"]," +
+		"\n  \"limit_prefix\": [\"github.com/org/project\"]\n}",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: deadCodeProps,
+			Required:   []string{"moduleArgs"},
+		},
+	}, deadCodeTool)
+
+	// Register the findCycles tool (strongly connected components of the filtered callgraph)
+	findCyclesProps := map[string]interface{}{
+		"moduleArgs":    externalProps["moduleArgs"],
+		"dir":           externalProps["dir"],
+		"limit_keyword": externalProps["limit_keyword"],
+		"limit_prefix":  externalProps["limit_prefix"],
+		"ignore":        externalProps["ignore"],
+		"edge_kinds":    externalProps["edge_kinds"],
+		"nostd":         internalProps["nostd"],
+		"refresh":       externalProps["refresh"],
+		"algo":          externalProps["algo"],
+		"vta_seed":      externalProps["vta_seed"],
+		"rta_roots":     externalProps["rta_roots"],
+		"roots":         externalProps["roots"],
+		"tests":         internalProps["tests"],
+		"timeout_ms":    externalProps["timeout_ms"],
+		"nointer": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Omit calls to unexported functions (default: false, since recursion usually goes through unexported helpers)",
+			"default":     false,
+		},
+		"granularity": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"func", "type", "pkg"},
+			"description": "Find cycles between functions (recursion), receiver types, or packages (package-level call cycles, e.g. through callbacks; calls inside a package are ignored) (default: func)",
+			"default":     "func",
+		},
+		"output_format": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"text", "json", "mermaid", "dot", "svg"},
+			"description": "Readable cycle list, structured JSON, or a Mermaid, Graphviz DOT or SVG graph of the cycle members with each representative cycle drawn in red (default: text)",
+			"default":     "text",
+		},
+	}
+	mcpServer.AddTool(mcp.Tool{
+		Name:        "findCycles",
+		Description: "Find recursive and mutually recursive call chains in Go code: computes the strongly connected components of the filtered callgraph and reports each one " +
+		"with its members and one shortest cycle, with the call-site file:line of every hop (every merged call site for package or type hops). Use granularity 'pkg' to catch package-level call cycles" +
+		"\nExample:\n{" +
+		"\n  \"dir\": \"/path/to/project\"," +
+		"\n  \"moduleArgs\": [\"./...\"]," +
+		"\n  \"granularity\": \"pkg\"\n}",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: findCyclesProps,
+			Required:   []string{"moduleArgs"},
+		},
+	}, findCyclesTool)
+
+	// Register the callgraphMetrics tool (metrics over the callHierarchy package-level graph)
+	metricsProps := map[string]interface{}{
+		"moduleArgs":    externalProps["moduleArgs"],
+		"dir":           externalProps["dir"],
+		"focus":         internalProps["focus"],
+		"limit_keyword": externalProps["limit_keyword"],
+		"limit_prefix":  externalProps["limit_prefix"],
+		"ignore":        externalProps["ignore"],
+		"edge_kinds":    externalProps["edge_kinds"],
+		"nostd":         internalProps["nostd"],
+		"refresh":       externalProps["refresh"],
+		"algo":          externalProps["algo"],
+		"vta_seed":      externalProps["vta_seed"],
+		"rta_roots":     externalProps["rta_roots"],
+		"roots":         externalProps["roots"],
+		"tests":         internalProps["tests"],
+		"timeout_ms":    externalProps["timeout_ms"],
+		"nointer": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Omit calls to unexported functions (default: false, hubs are often unexported)",
+			"default":     false,
+		},
+		"max_dep": map[string]interface{}{
+			"type":        "integer",
+			"description": "Only measure functions within this call depth from the roots (default: 0 = whole graph)",
+			"default":     0,
+		},
+		"sort_by": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"fan_in", "fan_out", "callers", "callees", "betweenness"},
+			"description": "Metric ranking the functions, highest first: fan_in/fan_out (direct callers/callees), callers/callees (transitive), betweenness (share of shortest call paths through the function) (default: betweenness)",
+			"default":     "betweenness",
+		},
+		"top_n": map[string]interface{}{
+			"type":        "integer",
+			"description": "Number of top functions to report (default 20); packages are always all reported",
+			"default":     20,
+		},
+		"output_format": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"markdown", "json", "both"},
+			"description": "Markdown tables, JSON, or both as two contents (default: both)",
+			"default":     "both",
+		},
+	}
+	mcpServer.AddTool(mcp.Tool{
+		Name:        "callgraphMetrics",
+		Description: "Rank the functions of a Go program by fan-in, fan-out, transitive callers/callees, depth from the roots and betweenness centrality, " +
+		"and report afferent/efferent coupling and instability per package. Answers questions like 'what are the riskiest hubs in this service?' without reading a diagram" +
+		"\nExample:\n{" +
+		"\n  \"dir\": \"/path/to/project\"," +
+		"\n  \"moduleArgs\": [\"./...\"]," +
+		"\n  \"limit_prefix\": [\"github.com/org/project\"]," +
+		"\n  \"top_n\": 10\n}",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: metricsProps,
+			Required:   []string{"moduleArgs"},
+		},
+	}, metricsTool)
+
+	// Expose the workspace roots requests are restricted to
+	mcpServer.AddResource(mcp.NewResource(
+		handlers.RootsResourceURI,
+		"Allowed roots",
+		mcp.WithResourceDescription("Directories that dir and moduleArgs must resolve inside (set by MCP_ROOTS); restricted is false when any path is accepted"),
+		mcp.WithMIMEType("application/json"),
+	), handlers.HandleRootsResource)
+
+	// Expose the graphs analyzed by callHierarchy, parameterized by traversal start
+	mcpServer.AddResourceTemplate(mcp.NewResourceTemplate(
+		handlers.GraphResourceTemplate,
+		"Callgraph",
+		mcp.WithTemplateDescription("A graph analyzed by callHierarchy, re-filtered from the cached analysis on each read. "+
+			"workspace and hash come from the resource links returned by callHierarchy; view is mermaid, json, dot or packages (JSON of the package-level graph). "+
+			"symbol and direction (downstream, upstream or both) make it a traversal from symbol. "+
+			"Subscriptions are not supported: re-read the resource to pick up code changes"),
+	), handlers.HandleGraphResource)
+
+	// Register prompts for common code-navigation workflows, built on callHierarchy
+	dirArg := mcp.WithArgument("dir", mcp.RequiredArgument(), mcp.ArgumentDescription("Absolute path of the codebase to analyze"))
+	moduleArgsArg := mcp.WithArgument("moduleArgs", mcp.ArgumentDescription("Package patterns to analyze, separated by commas or spaces (default: ./...)"))
+	mcpServer.AddPrompt(mcp.NewPrompt(handlers.PromptExplainArchitecture,
+		mcp.WithPromptDescription("Explain the architecture of a service from its package-level callgraph: layers, request flow and central packages"),
+		dirArg,
+		moduleArgsArg,
+		mcp.WithArgument("limit_prefix", mcp.ArgumentDescription("Only include packages under this import path prefix, e.g. the module path")),
+	), handlers.HandleExplainArchitecturePrompt)
+	mcpServer.AddPrompt(mcp.NewPrompt(handlers.PromptChangeImpact,
+		mcp.WithPromptDescription("Find what breaks if a function changes, from the callers of the symbol"),
+		dirArg,
+		mcp.WithArgument("symbol", mcp.RequiredArgument(), mcp.ArgumentDescription("Function or method to change, written as for callHierarchy (e.g. 'pkg.Func', '(*Server).Handle')")),
+		mcp.WithArgument("direction", mcp.ArgumentDescription("upstream (callers), downstream or both (default: upstream)")),
+		moduleArgsArg,
+	), handlers.HandleChangeImpactPrompt)
+	mcpServer.AddPrompt(mcp.NewPrompt(handlers.PromptTraceRequest,
+		mcp.WithPromptDescription("Trace a request from an entry point such as an HTTP handler down to the database or other storage"),
+		dirArg,
+		mcp.WithArgument("symbol", mcp.RequiredArgument(), mcp.ArgumentDescription("Entry point of the request, e.g. the HTTP handler function")),
+		mcp.WithArgument("sink", mcp.ArgumentDescription("Package path keyword the trace should end in, e.g. 'database/sql' or 'redis' (includes standard library calls)")),
+		mcp.WithArgument("direction", mcp.ArgumentDescription("downstream (callees), upstream or both (default: downstream)")),
+		moduleArgsArg,
+	), handlers.HandleTraceRequestPrompt)
+
 	// Set up logging
 	if len(os.Args) > 1 && os.Args[1] == "--debug" {
 		log.SetOutput(os.Stderr)
@@ -147,23 +567,102 @@ func main() {
 		log.SetOutput(os.Stderr) // For now, always log to stderr for debugging
 	}
 
+	// Size of the in-memory analysis cache (number of analyzed programs kept; lost on restart)
+	if v := os.Getenv("MCP_CACHE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			handlers.SetAnalysisCacheSize(n)
+		} else {
+			log.Printf("Ignoring invalid MCP_CACHE_SIZE %q: %v", v, err)
+		}
+	}
+
+	// Default request timeout, overridden per request by timeout_ms
+	if v := os.Getenv("MCP_TIMEOUT_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			handlers.SetDefaultTimeout(time.Duration(n) * time.Millisecond)
+		} else {
+			log.Printf("Ignoring invalid MCP_TIMEOUT_MS %q: %v", v, err)
+		}
+	}
+
+	// Directories dir and moduleArgs must resolve inside, separated like PATH
+	if v := os.Getenv("MCP_ROOTS"); v != "" {
+		if err := handlers.SetAllowedRoots(filepath.SplitList(v)); err != nil {
+			log.Fatalf("Invalid MCP_ROOTS: %v", err)
+		}
+		log.Printf("Restricting analyses to %s", strings.Join(handlers.AllowedRoots(), ", "))
+	}
+
 	// Choose transport based on environment
     transport := os.Getenv("MCP_TRANSPORT")
-    if transport == "sse" {
-        addr := os.Getenv("MCP_ADDR")
-        if addr == "" {
-            addr = ":11156"
+    addr := os.Getenv("MCP_ADDR")
+    if addr == "" {
+        addr = ":11156"
+    }
+    switch transport {
+    case "sse", "http":
+        // Network transports check a bearer token or API key (comma-separated to allow rotation)
+        tokens := strings.Split(os.Getenv("MCP_AUTH_TOKEN"), ",")
+        if os.Getenv("MCP_AUTH_TOKEN") == "" {
+            log.Printf("Warning: MCP_AUTH_TOKEN is not set, any client can connect")
         }
-        log.Printf("Starting callgraph-mcp SSE server on %s", addr)
-        sseServer := server.NewSSEServer(mcpServer)
-        if err := sseServer.Start(addr); err != nil {
-            log.Fatalf("Server error: %v", err)
+        if len(handlers.AllowedRoots()) == 0 {
+            log.Printf("Warning: MCP_ROOTS is not set, clients can analyze any path on this host")
+        }
+        srv := &http.Server{Addr: addr}
+        if transport == "sse" {
+            log.Printf("Starting callgraph-mcp SSE server on %s", addr)
+            sseServer := server.NewSSEServer(mcpServer, server.WithHTTPServer(srv))
+            srv.Handler = handlers.RequireToken(tokens, sseServer)
+            serveUntilSignal(func() error { return sseServer.Start(addr) }, sseServer.Shutdown)
+            break
         }
-    } else {
+        // Streamable HTTP: a single endpoint with session IDs and resumable streams
+        path, err := handlers.HTTPEndpointPath(os.Getenv("MCP_HTTP_PATH"))
+        if err != nil {
+            log.Fatalf("Invalid MCP_HTTP_PATH: %v", err)
+        }
+        log.Printf("Starting callgraph-mcp streamable HTTP server on %s%s", addr, path)
+        httpServer := server.NewStreamableHTTPServer(mcpServer, server.WithEndpointPath(path), server.WithStreamableHTTPServer(srv))
+        srv.Handler = handlers.NewHTTPHandler(httpServer, path, tokens)
+        serveUntilSignal(func() error { return httpServer.Start(addr) }, httpServer.Shutdown)
+    default:
         log.Printf("Starting callgraph-mcp stdio server...")
         if err := server.ServeStdio(mcpServer); err != nil {
             log.Fatalf("Server error: %v", err)
         }
     }
 }
+
+// shutdownTimeout bounds how long in-flight requests may run on SIGTERM
+const shutdownTimeout = 30 * time.Second
+
+// serveUntilSignal runs an HTTP transport until it fails or SIGINT/SIGTERM
+// arrives; requests in flight then get shutdownTimeout to complete
+func serveUntilSignal(start func() error, shutdown func(context.Context) error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- start() }()
+	select {
+	case err := <-errc:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server error: %v", err)
+		}
+		return
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down, waiting up to %v for requests in flight", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown error: %v", err)
+	}
+	select {
+	case <-errc:
+	case <-shutdownCtx.Done():
+	}
+}
 // Remove old 'include' property entirely per request
\ No newline at end of file
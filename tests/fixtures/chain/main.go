@@ -0,0 +1,25 @@
+package main
+
+func main() {
+	handle()
+	direct()
+}
+
+func handle() {
+	validate()
+	store()
+}
+
+func validate() {
+	store()
+}
+
+func direct() {
+	save()
+}
+
+func save() {
+	store()
+}
+
+func store() {}
@@ -0,0 +1,39 @@
+// Package main has direct, mutual and cross-package recursion
+package main
+
+import (
+	"fmt"
+
+	"callgraph-mcp/tests/fixtures/cycles/walker"
+)
+
+func fact(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return n * fact(n-1)
+}
+
+func isEven(n int) bool {
+	if n == 0 {
+		return true
+	}
+	return isOdd(n - 1)
+}
+
+func isOdd(n int) bool {
+	if n == 0 {
+		return false
+	}
+	return isEven(n - 1)
+}
+
+// visit walks again from each visited node: main and walker call each other
+func visit(n int) {
+	walker.Walk(n-1, visit)
+}
+
+func main() {
+	visit(3)
+	fmt.Println(fact(5), isEven(4))
+}
@@ -0,0 +1,9 @@
+// Package walker calls back into its caller
+package walker
+
+// Walk calls visit with n while n is positive
+func Walk(n int, visit func(int)) {
+	if n > 0 {
+		visit(n)
+	}
+}
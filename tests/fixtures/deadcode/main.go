@@ -0,0 +1,66 @@
+// Package main has functions that RTA cannot reach from main
+package main
+
+import "fmt"
+
+type shape interface {
+	area() int
+}
+
+type square struct {
+	side int
+}
+
+func (s square) area() int { return s.side * s.side }
+
+func (s square) perimeter() int { return 4 * s.side }
+
+// circle is never instantiated, so its method is unreachable
+type circle struct{}
+
+func (circle) area() int { return 3 }
+
+// Box holds a value of any type
+type Box[T any] struct {
+	v T
+}
+
+func (b Box[T]) Get() T { return b.v }
+
+func (b Box[T]) Set(v T) { b.v = v }
+
+// Max is used through an instantiation
+func Max[T int | float64](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Min is never instantiated
+func Min[T int | float64](a, b T) T {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Exported is unused, although exported
+func Exported() {}
+
+func unused() {
+	helper()
+}
+
+func helper() {}
+
+func run(f func()) { f() }
+
+func callback() {}
+
+func main() {
+	var s shape = square{side: 2}
+	b := Box[int]{v: 1}
+	run(callback)
+	fmt.Println(s.area(), Max(1, 2), b.Get())
+}
@@ -0,0 +1,29 @@
+package main
+
+type Runner interface {
+	Run()
+}
+
+type job struct{}
+
+func (job) Run() {}
+
+func direct() {}
+
+func worker() {}
+
+func cleanup() {}
+
+func call(f func()) { f() }
+
+func main() {
+	direct()
+
+	var r Runner = job{}
+	r.Run()
+
+	call(func() { worker() })
+
+	go worker()
+	defer cleanup()
+}
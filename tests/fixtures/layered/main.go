@@ -0,0 +1,9 @@
+package main
+
+import "callgraph-mcp/tests/fixtures/layered/service"
+
+func main() {
+	svc := service.New()
+	svc.Create("a")
+	svc.Create("b")
+}
@@ -0,0 +1,19 @@
+package service
+
+import "callgraph-mcp/tests/fixtures/layered/store"
+
+type Service struct {
+	st *store.Store
+}
+
+func New() *Service {
+	return &Service{st: store.Open()}
+}
+
+func (s *Service) Create(key string) {
+	s.st.Put(key)
+	s.st.Put(key + "/meta")
+	s.log(key)
+}
+
+func (s *Service) log(key string) {}
@@ -0,0 +1,17 @@
+package store
+
+type Store struct {
+	keys map[string]bool
+}
+
+func Open() *Store {
+	return &Store{keys: make(map[string]bool)}
+}
+
+func (s *Store) Put(key string) {
+	s.index(key)
+}
+
+func (s *Store) index(key string) {
+	s.keys[key] = true
+}
@@ -0,0 +1,33 @@
+// Package library has no main package: RTA needs other roots to analyze it
+package library
+
+// Store is a key/value store
+type Store struct {
+	items map[string]string
+}
+
+// NewStore returns an empty store
+func NewStore() *Store {
+	return &Store{items: make(map[string]string)}
+}
+
+// Put stores v under k
+func (s *Store) Put(k, v string) {
+	s.validate(k)
+	s.items[k] = v
+}
+
+func (s *Store) validate(k string) {
+	if k == "" {
+		panic("empty key")
+	}
+}
+
+// Lookup returns the value stored under k
+func Lookup(s *Store, k string) string {
+	return s.get(k)
+}
+
+func (s *Store) get(k string) string {
+	return s.items[k]
+}
@@ -0,0 +1,15 @@
+package library
+
+import "testing"
+
+func TestPut(t *testing.T) {
+	s := NewStore()
+	s.Put("k", "v")
+	check(t, s)
+}
+
+func check(t *testing.T, s *Store) {
+	if len(s.items) != 1 {
+		t.Fatal("expected one item")
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+// Plugin is implemented by both Audio and Video, but each call site only
+// ever sees one of them: CHA and RTA resolve both calls to both methods,
+// VTA to the single method whose type flows there.
+type Plugin interface {
+	Run()
+}
+
+type Audio struct{}
+
+func (Audio) Run() { decode() }
+
+type Video struct{}
+
+func (Video) Run() { render() }
+
+func decode() {}
+
+func render() {}
+
+func runAudio(p Plugin) { p.Run() }
+
+func runVideo(p Plugin) { p.Run() }
+
+func main() {
+	runAudio(Audio{})
+	runVideo(Video{})
+}
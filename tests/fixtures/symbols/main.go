@@ -0,0 +1,63 @@
+package main
+
+import "fmt"
+
+// Base is embedded in Server, which promotes Close.
+type Base struct{}
+
+func (b *Base) Close() {}
+
+// Server and Client both have a Handle method, so "Handle" is ambiguous.
+type Server struct {
+	Base
+}
+
+func (s *Server) Handle() {
+	s.log("handle")
+}
+
+func (s *Server) log(msg string) {
+	fmt.Println(msg)
+}
+
+type Client struct{}
+
+func (c Client) Handle() {
+	send()
+}
+
+func send() {}
+
+// Stack is a generic type with a method.
+type Stack[T any] struct {
+	items []T
+}
+
+func (s *Stack[T]) Push(v T) {
+	s.items = append(s.items, v)
+}
+
+// Map is a generic function.
+func Map[T, U any](xs []T, f func(T) U) []U {
+	out := make([]U, 0, len(xs))
+	for _, x := range xs {
+		out = append(out, f(x))
+	}
+	return out
+}
+
+func main() {
+	s := &Server{}
+	s.Handle()
+	s.Close()
+	Client{}.Handle()
+
+	run := func() {
+		send()
+	}
+	run()
+
+	st := &Stack[int]{}
+	st.Push(1)
+	Map([]int{1}, func(i int) string { return fmt.Sprint(i) })
+}
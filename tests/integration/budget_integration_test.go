@@ -0,0 +1,164 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+// budgetGraph renders the whole layered fixture within max_nodes
+func budgetGraph(t *testing.T, maxNodes int) *handlers.MCPCallgraphResponse {
+	t.Helper()
+	result := callTool(t, "callHierarchy", handlers.HandleCallgraphRequest, map[string]interface{}{
+		"moduleArgs":    []string{"../fixtures/layered"},
+		"nointer":       false,
+		"max_dep":       0,
+		"output_format": "json",
+	}, map[string]interface{}{"max_nodes": maxNodes})
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	var resp handlers.MCPCallgraphResponse
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if resp.Stats.NodeCount > maxNodes {
+		t.Errorf("expected at most %d nodes, got %d", maxNodes, resp.Stats.NodeCount)
+	}
+	return &resp
+}
+
+func TestBudgetWithinLimit(t *testing.T) {
+	resp := budgetGraph(t, 7)
+	if resp.Stats.NodeCount != 7 || len(resp.Notes) != 0 {
+		t.Errorf("expected the full graph without notes, got %d nodes and notes %v", resp.Stats.NodeCount, resp.Notes)
+	}
+}
+
+func TestBudgetCollapsesPackages(t *testing.T) {
+	resp := budgetGraph(t, 5)
+	if len(resp.Notes) != 1 || !strings.Contains(resp.Notes[0], "collapsed 2 of 3 packages") {
+		t.Fatalf("expected a collapse note, got %v", resp.Notes)
+	}
+	// service is the most central package and stays expanded
+	for _, n := range resp.Graph.Nodes {
+		if n.ID == layered+"/store" && n.Members != 3 {
+			t.Errorf("expected store collapsed into one node, got %+v", n)
+		}
+		if strings.HasPrefix(n.ID, "(*"+layered+"/store") {
+			t.Errorf("unexpected store function %s", n.ID)
+		}
+	}
+}
+
+func TestBudgetPrunesLeaves(t *testing.T) {
+	resp := budgetGraph(t, 4)
+	notes := strings.Join(resp.Notes, "\n")
+	if !strings.Contains(notes, "pruned 1 leaf functions deeper than 1 calls") || !strings.Contains(notes, `symbol "(*`+layered+`/service.Service).Create"`) {
+		t.Errorf("expected a pruning note pointing at Create, got %v", resp.Notes)
+	}
+}
+
+func TestBudgetPackageView(t *testing.T) {
+	resp := budgetGraph(t, 3)
+	if len(resp.Notes) != 1 || !strings.Contains(resp.Notes[0], "switched to the package-level view") {
+		t.Fatalf("expected a package view note, got %v", resp.Notes)
+	}
+	if resp.Stats.NodeCount != 3 || resp.Stats.EdgeCount != 2 {
+		t.Errorf("expected 3 packages and 2 edges, got %+v", resp.Stats)
+	}
+
+	result, err := handlers.HandleCallgraphRequest(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: map[string]interface{}{
+			"moduleArgs": []string{"../fixtures/layered"},
+			"nointer":    false,
+			"max_dep":    0,
+			"max_nodes":  2,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	mermaid := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(mermaid, "\n%% note: output budget (max_nodes 2): the package-level view still exceeds the budget") {
+		t.Errorf("expected trailing budget notes:\n%s", mermaid)
+	}
+}
+
+func TestBudgetEmptyGraph(t *testing.T) {
+	for name, args := range map[string]map[string]interface{}{
+		"filtered": {"limit_prefix": []string{"nonexistent"}},
+		"symbol":   {"symbol": "Create", "limit_prefix": []string{"nonexistent"}},
+	} {
+		args["moduleArgs"] = []string{"../fixtures/layered"}
+		args["max_output_bytes"] = 5
+		result, err := handlers.HandleCallgraphRequest(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("%s: HandleCallgraphRequest failed: %v", name, err)
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		if result.IsError || !strings.Contains(text, "the empty graph still exceeds the budget") {
+			t.Errorf("%s: expected an empty graph with a budget note, got:\n%s", name, text)
+		}
+	}
+}
+
+func TestBudgetBoundsOutputBytes(t *testing.T) {
+	// A long call chain, so that every format has room to degrade into
+	dir := t.TempDir()
+	var src strings.Builder
+	src.WriteString("package main\n\nfunc main() { step0() }\n")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&src, "\nfunc step%d() { step%d() }\n", i, i+1)
+	}
+	src.WriteString("\nfunc step30() {}\n")
+	writeModule(t, dir, src.String())
+
+	render := func(format string, budget int) (string, bool) {
+		t.Helper()
+		result, err := handlers.HandleCallgraphRequest(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: map[string]interface{}{
+				"dir":              dir,
+				"moduleArgs":       []string{"."},
+				"algo":             "static",
+				"nointer":          false,
+				"max_dep":          0,
+				"max_output_bytes": budget,
+				"output_format":    format,
+			}},
+		})
+		if err != nil {
+			t.Fatalf("HandleCallgraphRequest failed: %v", err)
+		}
+		var text string
+		for _, c := range result.Content {
+			if tc, ok := c.(mcp.TextContent); ok {
+				text += tc.Text
+			}
+		}
+		if result.IsError {
+			t.Fatalf("%s: unexpected error: %s", format, text)
+		}
+		return text, strings.Contains(text, "still exceeds the budget")
+	}
+	for _, format := range []string{"mermaid", "json", "both", "dot", "svg"} {
+		full, _ := render(format, 0)
+		for _, budget := range []int{len(full) - 1, len(full) / 2} {
+			text, exceeded := render(format, budget)
+			if exceeded {
+				t.Errorf("%s: expected a view within %d bytes, got the over-budget note", format, budget)
+			} else if len(text) > budget {
+				t.Errorf("%s: output of %d bytes exceeds max_output_bytes %d:\n%s", format, len(text), budget, text)
+			}
+		}
+	}
+}
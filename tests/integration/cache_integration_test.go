@@ -0,0 +1,134 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+// writeModule writes a throwaway module with a single main.go
+func writeModule(t *testing.T, dir string, src string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/cachetest\n\ngo 1.21\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func callDownstream(t *testing.T, dir string, refresh bool) string {
+	t.Helper()
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "callHierarchy",
+			Arguments: map[string]interface{}{
+				"moduleArgs": []string{"./..."},
+				"dir":        dir,
+				"algo":       "static",
+				"nostd":      true,
+				"nointer":    false,
+				"symbol":     "main.main",
+				"direction":  "downstream",
+				"refresh":    refresh,
+			},
+		},
+	}
+	result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatal("result content is not TextContent")
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", textContent.Text)
+	}
+	return textContent.Text
+}
+
+func TestAnalysisCacheInvalidatedBySourceChange(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "package main\n\nfunc alpha() {}\n\nfunc main() { alpha() }\n")
+
+	first := callDownstream(t, dir, false)
+	if !strings.Contains(first, "alpha<br/>") {
+		t.Fatalf("expected alpha in first output: %q", first)
+	}
+
+	// Cached request must see the same graph
+	if again := callDownstream(t, dir, false); !strings.Contains(again, "alpha<br/>") {
+		t.Fatalf("expected alpha in cached output: %q", again)
+	}
+
+	writeModule(t, dir, "package main\n\nfunc alpha() {}\n\nfunc beta() {}\n\nfunc main() { alpha(); beta() }\n")
+	second := callDownstream(t, dir, false)
+	if !strings.Contains(second, "beta<br/>") {
+		t.Fatalf("expected stale cache to be rebuilt with beta: %q", second)
+	}
+}
+
+func TestAnalysisCacheRefresh(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "package main\n\nfunc alpha() {}\n\nfunc main() { alpha() }\n")
+
+	if out := callDownstream(t, dir, false); !strings.Contains(out, "alpha<br/>") {
+		t.Fatalf("expected alpha in output: %q", out)
+	}
+	if out := callDownstream(t, dir, true); !strings.Contains(out, "alpha<br/>") {
+		t.Fatalf("expected alpha in refreshed output: %q", out)
+	}
+}
+
+func TestAnalysisBuildTagsPerRequest(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "package main\n\nfunc main() { extra() }\n")
+	files := map[string]string{
+		"extra.go":   "//go:build extra\n\npackage main\n\nfunc extra() { tagged() }\n\nfunc tagged() {}\n",
+		"noextra.go": "//go:build !extra\n\npackage main\n\nfunc extra() {}\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	call := func(tags []string) string {
+		t.Helper()
+		args := map[string]interface{}{
+			"moduleArgs": []string{"./..."},
+			"dir":        dir,
+			"algo":       "static",
+			"nointer":    false,
+			"symbol":     "main.main",
+		}
+		if tags != nil {
+			args["tags"] = tags
+		}
+		result, err := handlers.HandleCallgraphRequest(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: args},
+		})
+		if err != nil {
+			t.Fatalf("HandleCallgraphRequest failed: %v", err)
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		if result.IsError {
+			t.Fatalf("unexpected error result: %s", text)
+		}
+		return text
+	}
+	if out := call([]string{"extra"}); !strings.Contains(out, "tagged") {
+		t.Errorf("expected the extra build tag to select tagged:\n%s", out)
+	}
+	// Tags apply to their own request only
+	if out := call(nil); strings.Contains(out, "tagged") {
+		t.Errorf("expected no build tags without tags:\n%s", out)
+	}
+}
@@ -0,0 +1,141 @@
+package integration
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+func callPath(t *testing.T, args map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+	return callTool(t, "callPath", handlers.HandleCallPathRequest, map[string]interface{}{
+		"moduleArgs": []string{"../fixtures/chain"},
+		"algo":       "static",
+		"nostd":      true,
+		"nointer":    false,
+	}, args)
+}
+
+func decodePaths(t *testing.T, result *mcp.CallToolResult) handlers.MCPCallPathResponse {
+	t.Helper()
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	var resp handlers.MCPCallPathResponse
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, text)
+	}
+	return resp
+}
+
+// pathFuncs returns the function names along a path, e.g. "main>handle>store"
+func pathFuncs(p handlers.MCPCallPath) string {
+	short := func(id string) string { return id[strings.LastIndex(id, ".")+1:] }
+	names := []string{short(p.Hops[0].Caller)}
+	for _, h := range p.Hops {
+		names = append(names, short(h.Callee))
+	}
+	return strings.Join(names, ">")
+}
+
+func TestCallPathShortest(t *testing.T) {
+	resp := decodePaths(t, callPath(t, map[string]interface{}{
+		"from":          "main.main",
+		"to":            "store",
+		"output_format": "json",
+	}))
+	if len(resp.Paths) != 1 {
+		t.Fatalf("expected exactly one shortest path, got %+v", resp.Paths)
+	}
+	if got := pathFuncs(resp.Paths[0]); got != "main>handle>store" {
+		t.Fatalf("unexpected shortest path %s", got)
+	}
+	for _, h := range resp.Paths[0].Hops {
+		if !strings.HasSuffix(h.File, "main.go") || h.Line == 0 {
+			t.Errorf("hop %s -> %s has no call site", h.Caller, h.Callee)
+		}
+	}
+}
+
+func TestCallPathKShortestAndAll(t *testing.T) {
+	resp := decodePaths(t, callPath(t, map[string]interface{}{
+		"from":          "main.main",
+		"to":            "store",
+		"mode":          "k_shortest",
+		"k":             2,
+		"output_format": "json",
+	}))
+	if len(resp.Paths) != 2 || resp.Paths[0].Length != 2 || resp.Paths[1].Length != 3 {
+		t.Fatalf("unexpected k shortest paths: %+v", resp.Paths)
+	}
+	if !resp.Truncated {
+		t.Error("expected truncated with a second path of length 3 left out")
+	}
+
+	// The single shortest path fills k before max_length: longer ones may exist
+	resp = decodePaths(t, callPath(t, map[string]interface{}{
+		"from":          "main.main",
+		"to":            "store",
+		"mode":          "k_shortest",
+		"k":             1,
+		"output_format": "json",
+	}))
+	if len(resp.Paths) != 1 || !resp.Truncated {
+		t.Errorf("expected one path marked truncated, got %+v", resp)
+	}
+
+	resp = decodePaths(t, callPath(t, map[string]interface{}{
+		"from":          "main.main",
+		"to":            "store",
+		"mode":          "all",
+		"output_format": "json",
+	}))
+	got := make(map[string]bool)
+	for _, p := range resp.Paths {
+		got[pathFuncs(p)] = true
+	}
+	for _, want := range []string{"main>handle>store", "main>handle>validate>store", "main>direct>save>store"} {
+		if !got[want] {
+			t.Errorf("missing path %s in %v", want, got)
+		}
+	}
+
+	resp = decodePaths(t, callPath(t, map[string]interface{}{
+		"from":          "main.main",
+		"to":            "store",
+		"mode":          "all",
+		"max_length":    2,
+		"output_format": "json",
+	}))
+	if len(resp.Paths) != 1 {
+		t.Fatalf("max_length should bound paths, got %+v", resp.Paths)
+	}
+}
+
+func TestCallPathTextAndErrors(t *testing.T) {
+	result := callPath(t, map[string]interface{}{"from": "direct", "to": "store"})
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError || !strings.Contains(text, "Path 1 (2 calls)") || !strings.Contains(text, "main.go:") {
+		t.Fatalf("unexpected text output: %s", text)
+	}
+
+	result = callPath(t, map[string]interface{}{"from": "main.main", "to": "store", "mode": "k_shortest", "k": 1})
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "(more paths may exist within max_length; increase k to list them)") {
+		t.Errorf("expected a k_shortest truncation hint, got: %s", text)
+	}
+
+	result = callPath(t, map[string]interface{}{"from": "store", "to": "main.main"})
+	if text := result.Content[0].(mcp.TextContent).Text; result.IsError || !strings.HasPrefix(text, "No call path found") {
+		t.Fatalf("expected no path, got: %s", text)
+	}
+
+	result = callPath(t, map[string]interface{}{"from": "main.main", "to": "missing"})
+	if !result.IsError {
+		t.Fatal("expected error for unknown symbol")
+	}
+}
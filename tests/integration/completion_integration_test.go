@@ -0,0 +1,165 @@
+package integration
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"callgraph-mcp/handlers"
+)
+
+func TestArgumentCompletion(t *testing.T) {
+	layered, err := filepath.Abs("../fixtures/layered")
+	if err != nil {
+		t.Fatalf("Abs failed: %v", err)
+	}
+
+	mcpServer := server.NewMCPServer("callgraph-mcp", "test",
+		server.WithResourceCapabilities(false, true),
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(handlers.CompletionProvider{}),
+		server.WithResourceCompletionProvider(handlers.CompletionProvider{}),
+	)
+	mcpServer.AddTool(mcp.Tool{
+		Name:        "callHierarchy",
+		InputSchema: mcp.ToolInputSchema{Type: "object"},
+	}, handlers.HandleCallgraphRequest)
+	mcpServer.AddPrompt(mcp.NewPrompt(handlers.PromptChangeImpact), handlers.HandleChangeImpactPrompt)
+	mcpServer.AddResourceTemplate(mcp.NewResourceTemplate(handlers.GraphResourceTemplate, "Callgraph"), handlers.HandleGraphResource)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	c, err := client.NewInProcessClient(mcpServer)
+	if err != nil {
+		t.Fatalf("NewInProcessClient failed: %v", err)
+	}
+	defer c.Close()
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+	initResult, err := c.Initialize(ctx, initRequest)
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if initResult.Capabilities.Completions == nil {
+		t.Error("expected the completions capability")
+	}
+
+	complete := func(ref interface{}, name, value string, args map[string]string) []string {
+		t.Helper()
+		request := mcp.CompleteRequest{}
+		request.Params.Ref = ref
+		request.Params.Argument.Name = name
+		request.Params.Argument.Value = value
+		request.Params.Context.Arguments = args
+		result, err := c.Complete(ctx, request)
+		if err != nil {
+			t.Fatalf("Complete %s=%q failed: %v", name, value, err)
+		}
+		return result.Completion.Values
+	}
+	callTool := func(dir string) {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "callHierarchy"
+		request.Params.Arguments = map[string]any{"dir": dir, "moduleArgs": []string{"./..."}, "algo": "static"}
+		if result, err := c.CallTool(ctx, request); err != nil || result.IsError {
+			t.Fatalf("CallTool failed: %v %+v", err, result)
+		}
+	}
+	prompt := mcp.PromptReference{Type: "ref/prompt", Name: handlers.PromptChangeImpact}
+
+	// Completion never loads packages: symbols come only from a fresh cached analysis
+	fresh := t.TempDir()
+	writeModule(t, fresh, "package main\n\nfunc helper() {}\n\nfunc main() { helper() }\n")
+	freshArgs := map[string]string{"dir": fresh, "moduleArgs": "./..."}
+	if values := complete(prompt, "symbol", "help", freshArgs); len(values) != 0 {
+		t.Errorf("expected no symbol completions before any analysis, got %v", values)
+	}
+	if values := complete(prompt, "moduleArgs", "./", freshArgs); !containsString(values, "./...") {
+		t.Errorf("expected moduleArgs completions without an analysis, got %v", values)
+	}
+	callTool(fresh)
+	if values := complete(prompt, "symbol", "help", freshArgs); len(values) == 0 || !strings.HasSuffix(values[0], ".helper") {
+		t.Errorf("expected helper once analyzed, got %v", values)
+	}
+	writeModule(t, fresh, "package main\n\nfunc helper2() {}\n\nfunc main() { helper2() }\n")
+	if values := complete(prompt, "symbol", "help", freshArgs); len(values) != 0 {
+		t.Errorf("expected no symbol completions from a stale analysis, got %v", values)
+	}
+
+	callTool(layered)
+	filled := map[string]string{"dir": layered, "moduleArgs": "./..."}
+
+	// Mistyped and partial symbols: prefix and fuzzy matches over function and method names
+	values := complete(prompt, "symbol", "creat", filled)
+	if len(values) == 0 || !strings.HasSuffix(values[0], ".Create") {
+		t.Errorf("expected (*Service).Create first for 'creat', got %v", values)
+	}
+	values = complete(prompt, "symbol", "storput", filled)
+	if len(values) == 0 || !strings.HasSuffix(values[0], "store.Store).Put") {
+		t.Errorf("expected (*Store).Put first for 'storput', got %v", values)
+	}
+
+	// Import paths, including parent prefixes for limit_prefix
+	values = complete(prompt, "limit_prefix", "callgraph-mcp/tests/fixtures/lay", filled)
+	if len(values) == 0 || values[0] != "callgraph-mcp/tests/fixtures/layered" {
+		t.Errorf("unexpected limit_prefix completions: %v", values)
+	}
+	values = complete(prompt, "sink", "stor", filled)
+	if len(values) == 0 || values[0] != "callgraph-mcp/tests/fixtures/layered/store" {
+		t.Errorf("unexpected sink completions: %v", values)
+	}
+
+	// Directory package patterns, completing the last of several
+	values = complete(prompt, "moduleArgs", "./service,./st", filled)
+	if len(values) == 0 || values[0] != "./service,./store" {
+		t.Errorf("unexpected moduleArgs completions: %v", values)
+	}
+	values = complete(prompt, "moduleArgs", "", map[string]string{"dir": layered})
+	for _, want := range []string{".", "./...", "./service", "./service/...", "./store"} {
+		if !containsString(values, want) {
+			t.Errorf("expected %q among the moduleArgs completions, got %v", want, values)
+		}
+	}
+
+	if values := complete(prompt, "direction", "up", nil); len(values) != 1 || values[0] != "upstream" {
+		t.Errorf("unexpected direction completions: %v", values)
+	}
+	// Without dir there is nothing to analyze
+	if values := complete(prompt, "symbol", "creat", nil); len(values) != 0 {
+		t.Errorf("expected no symbol completions without dir, got %v", values)
+	}
+
+	// Resource template arguments come from the registered graphs
+	template := mcp.ResourceReference{Type: "ref/resource", URI: handlers.GraphResourceTemplate}
+	if values := complete(template, "workspace", "lay", nil); len(values) != 1 || values[0] != "layered" {
+		t.Errorf("unexpected workspace completions: %v", values)
+	}
+	hashes := complete(template, "hash", "", map[string]string{"workspace": "layered"})
+	if len(hashes) == 0 {
+		t.Fatal("expected the hash of the layered graph")
+	}
+	if values := complete(template, "view", "pack", nil); len(values) != 1 || values[0] != "packages" {
+		t.Errorf("unexpected view completions: %v", values)
+	}
+	values = complete(template, "symbol", "Create", map[string]string{"workspace": "layered", "hash": hashes[len(hashes)-1]})
+	if len(values) == 0 || !strings.HasSuffix(values[0], ".Create") {
+		t.Errorf("unexpected resource symbol completions: %v", values)
+	}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
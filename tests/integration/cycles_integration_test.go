@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+const cyclesPkg = "callgraph-mcp/tests/fixtures/cycles"
+
+// findCycles runs the findCycles tool on the cycles fixture
+func findCycles(t *testing.T, args map[string]interface{}) (string, bool) {
+	t.Helper()
+	result := callTool(t, "findCycles", handlers.HandleFindCyclesRequest, map[string]interface{}{
+		"moduleArgs": []string{"../fixtures/cycles/..."},
+	}, args)
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+// cyclesJSON returns the cycles reported as JSON
+func cyclesJSON(t *testing.T, args map[string]interface{}) []handlers.MCPCycle {
+	t.Helper()
+	args["output_format"] = "json"
+	text, isError := findCycles(t, args)
+	if isError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	var resp handlers.MCPFindCyclesResponse
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	return resp.Cycles
+}
+
+func TestFindCyclesFunctions(t *testing.T) {
+	cycles := cyclesJSON(t, map[string]interface{}{})
+	var got []string
+	for _, c := range cycles {
+		got = append(got, strings.ReplaceAll(strings.Join(c.Members, " "), cyclesPkg, ""))
+	}
+	want := []string{".isEven .isOdd", ".visit /walker.Walk", ".fact"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected cycles %v, got %v", want, got)
+	}
+
+	// The representative cycle starts and ends at the first member, with call sites
+	path := cycles[1].Path
+	if len(path) != 2 || path[0].Caller != cyclesPkg+".visit" || path[1].Callee != cyclesPkg+".visit" || path[0].Callee != path[1].Caller {
+		t.Errorf("unexpected cycle path: %+v", path)
+	}
+	if !strings.HasSuffix(path[0].File, "/cycles/main.go") || path[0].Line != 33 || path[1].Kind != "closure" || !strings.HasSuffix(path[1].File, "/walker/walker.go") {
+		t.Errorf("unexpected call sites: %+v", path)
+	}
+	if fact := cycles[2].Path; len(fact) != 1 || fact[0].Caller != fact[0].Callee || fact[0].Line != 14 {
+		t.Errorf("expected fact to call itself at main.go:14, got %+v", fact)
+	}
+}
+
+func TestFindCyclesPackages(t *testing.T) {
+	cycles := cyclesJSON(t, map[string]interface{}{"granularity": "pkg"})
+	if len(cycles) != 1 || strings.Join(cycles[0].Members, " ") != cyclesPkg+" "+cyclesPkg+"/walker" {
+		t.Fatalf("expected one package cycle between main and walker, got %+v", cycles)
+	}
+	// Aggregated hops keep the call sites they merge, primary site first
+	for _, hop := range cycles[0].Path {
+		if len(hop.Sites) == 0 || hop.Sites[0].File != hop.File || hop.Sites[0].Line != hop.Line || len(hop.Sites) != hop.Count {
+			t.Errorf("expected the call sites of %s -> %s, got %+v", hop.Caller, hop.Callee, hop)
+		}
+	}
+
+	// limit_prefix drops the walker package, and the cycle with it
+	if cycles := cyclesJSON(t, map[string]interface{}{"granularity": "pkg", "limit_prefix": []string{cyclesPkg + "/walker"}}); len(cycles) != 0 {
+		t.Errorf("expected no cycle within walker, got %+v", cycles)
+	}
+}
+
+func TestFindCyclesOutput(t *testing.T) {
+	text, _ := findCycles(t, map[string]interface{}{})
+	if !strings.Contains(text, "Found 3 call cycle(s) (algo: rta, granularity: func)") || !strings.Contains(text, "Cycle 3 (recursive): "+cyclesPkg+".fact") {
+		t.Errorf("unexpected text output:\n%s", text)
+	}
+
+	mermaid, _ := findCycles(t, map[string]interface{}{"output_format": "mermaid"})
+	// Every edge lies on a cycle; the representative ones (all of them here) are drawn in red
+	if !regexp.MustCompile(`\nlinkStyle 0,1,2,3,4 stroke:#d62728`).MatchString(mermaid) || strings.Count(mermaid, "-->")+strings.Count(mermaid, "-.->") != 5 {
+		t.Errorf("expected five highlighted cycle edges:\n%s", mermaid)
+	}
+	if strings.Contains(mermaid, "main.main") {
+		t.Errorf("main is on no cycle:\n%s", mermaid)
+	}
+
+	// DOT and SVG draw the same edges in the same red
+	if dot, _ := findCycles(t, map[string]interface{}{"output_format": "dot"}); strings.Count(dot, `color="#d62728"`) != 5 {
+		t.Errorf("expected five red cycle edges:\n%s", dot)
+	}
+	if svg, _ := findCycles(t, map[string]interface{}{"output_format": "svg"}); strings.Count(svg, `stroke="#d62728"`) != 5 {
+		t.Errorf("expected five red cycle edges:\n%s", svg)
+	}
+
+	if text, isError := findCycles(t, map[string]interface{}{"output_format": "png"}); !isError || !strings.Contains(text, "invalid output_format") {
+		t.Errorf("expected an output_format error, got %q", text)
+	}
+}
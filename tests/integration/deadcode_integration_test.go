@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+// deadCode runs the deadCode tool and returns its text output and whether it is an error
+func deadCode(t *testing.T, args map[string]interface{}) (string, bool) {
+	t.Helper()
+	result := callTool(t, "deadCode", handlers.HandleDeadCodeRequest, nil, args)
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+// deadFuncs returns the unreachable functions reported as JSON, by Func
+func deadFuncs(t *testing.T, args map[string]interface{}) (*handlers.MCPDeadCodeResponse, map[string]handlers.MCPDeadFunction) {
+	t.Helper()
+	args["output_format"] = "json"
+	text, isError := deadCode(t, args)
+	if isError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	var resp handlers.MCPDeadCodeResponse
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	funcs := make(map[string]handlers.MCPDeadFunction)
+	for _, p := range resp.Packages {
+		for _, f := range p.Functions {
+			funcs[f.Func] = f
+		}
+	}
+	return &resp, funcs
+}
+
+func TestDeadCodeFromMain(t *testing.T) {
+	resp, funcs := deadFuncs(t, map[string]interface{}{"moduleArgs": []string{"../fixtures/deadcode"}})
+	want := []string{"(square).perimeter", "(circle).area", "(Box[T]).Set", "Min", "Exported", "unused", "helper"}
+	if len(funcs) != len(want) {
+		t.Errorf("expected %d unreachable functions, got %v", len(want), funcs)
+	}
+	for _, name := range want {
+		if _, ok := funcs[name]; !ok {
+			t.Errorf("expected %s to be reported unreachable, got %v", name, funcs)
+		}
+	}
+	for _, name := range []string{"main", "(square).area", "(Box[T]).Get", "Max", "run", "callback"} {
+		if _, ok := funcs[name]; ok {
+			t.Errorf("%s is reachable but was reported", name)
+		}
+	}
+	if !funcs["Exported"].Exported || funcs["unused"].Exported || funcs["unused"].File != "main.go" || funcs["unused"].Line == 0 {
+		t.Errorf("unexpected details: %+v %+v", funcs["Exported"], funcs["unused"])
+	}
+	if resp.Algorithm != "rta" || resp.RtaRoots != "auto" || resp.Roots != 1 || len(resp.Packages) != 1 {
+		t.Errorf("unexpected summary: %+v", resp)
+	}
+
+	text, _ := deadCode(t, map[string]interface{}{"moduleArgs": []string{"../fixtures/deadcode"}})
+	if !strings.Contains(text, "Found 7 unreachable function(s) in 1 package(s)") || !strings.Contains(text, "Exported  (exported)") {
+		t.Errorf("unexpected text report:\n%s", text)
+	}
+}
+
+func TestDeadCodeLibraryRoots(t *testing.T) {
+	_, funcs := deadFuncs(t, map[string]interface{}{"moduleArgs": []string{"../fixtures/library"}, "rta_roots": "exported"})
+	if len(funcs) != 0 {
+		t.Errorf("the exported API reaches every function, got %v", funcs)
+	}
+
+	resp, funcs := deadFuncs(t, map[string]interface{}{"moduleArgs": []string{"../fixtures/library"}, "rta_roots": "tests", "tests": true})
+	if len(funcs) != 2 || funcs["Lookup"].Func == "" || funcs["(*Store).get"].Func == "" {
+		t.Errorf("expected only Lookup and get unreachable from tests, got %v", funcs)
+	}
+	if resp.Roots != 1 {
+		t.Errorf("expected TestPut as the only root, got %d", resp.Roots)
+	}
+}
+
+func TestDeadCodeFilters(t *testing.T) {
+	_, funcs := deadFuncs(t, map[string]interface{}{"moduleArgs": []string{"../fixtures/deadcode"}, "ignore": []string{"deadcode"}})
+	if len(funcs) != 0 {
+		t.Errorf("expected ignore to drop the package, got %v", funcs)
+	}
+
+	text, isError := deadCode(t, map[string]interface{}{"moduleArgs": []string{"../fixtures/deadcode"}, "algo": "cha"})
+	if !isError || !strings.Contains(text, `only supports algo "rta"`) {
+		t.Errorf("expected an algo error, got %q", text)
+	}
+}
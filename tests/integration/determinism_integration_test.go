@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+var durationPattern = regexp.MustCompile(`"durationMs":\d+`)
+
+// render runs callHierarchy and returns its text output without the timing
+func render(t *testing.T, args map[string]interface{}) string {
+	t.Helper()
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: args}}
+	result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	var parts []string
+	for _, c := range result.Content {
+		parts = append(parts, c.(mcp.TextContent).Text)
+	}
+	text := strings.Join(parts, "\n")
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	return durationPattern.ReplaceAllString(text, `"durationMs":0`)
+}
+
+func TestOutputIsDeterministic(t *testing.T) {
+	var cases []map[string]interface{}
+	for _, format := range []string{"mermaid", "json", "dot", "svg"} {
+		for _, extra := range []map[string]interface{}{
+			{"group": []string{"pkg", "type"}},
+			{"group": []string{"type"}, "symbol": "main.main", "direction": "both"},
+			{"symbol": "main.main", "max_dep": 1},
+		} {
+			args := map[string]interface{}{
+				"moduleArgs":    []string{"../fixtures/symbols"},
+				"algo":          "static",
+				"nointer":       false,
+				"output_format": format,
+			}
+			for k, v := range extra {
+				args[k] = v
+			}
+			cases = append(cases, args)
+		}
+	}
+
+	var first []string
+	for round := 0; round < 3; round++ {
+		for i, args := range cases {
+			// Rebuilding the program reshuffles every map the graph came from
+			args["refresh"] = round > 0 && i == 0
+			text := render(t, args)
+			if round == 0 {
+				first = append(first, text)
+			} else if text != first[i] {
+				t.Fatalf("output %v differs between runs:\n%s\n---\n%s", args, first[i], text)
+			}
+		}
+	}
+}
+
+func TestMermaidIDsFollowSortedOrder(t *testing.T) {
+	text := render(t, map[string]interface{}{
+		"moduleArgs": []string{"../fixtures/simple"},
+		"algo":       "static",
+		"nointer":    false,
+		"symbol":     "main.main",
+	})
+	want := `flowchart LR
+subgraph "pkg:callgraph-mcp/tests/fixtures/simple"
+N1["goodbye<br/>main.go:`
+	if !strings.HasPrefix(text, want) {
+		t.Fatalf("expected nodes declared in sorted order, got:\n%s", text)
+	}
+	// Edges follow the compact IDs of their caller, then callee
+	edge := regexp.MustCompile(`^N(\d+) (?:-->|-\.->|==>|-- defer -->) N(\d+)$`)
+	prev := [2]int{}
+	for _, line := range strings.Split(text, "\n") {
+		m := edge.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		cur := [2]int{atoi(t, m[1]), atoi(t, m[2])}
+		if cur[0] < prev[0] || (cur[0] == prev[0] && cur[1] < prev[1]) {
+			t.Errorf("edge %q is out of order", line)
+		}
+		prev = cur
+	}
+}
+
+func atoi(t *testing.T, s string) int {
+	t.Helper()
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		t.Fatalf("not a number: %q", s)
+	}
+	return n
+}
@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+// kindsRequest runs a downstream traversal from main in the kinds fixture
+func kindsRequest(t *testing.T, args map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+	return callTool(t, "callHierarchy", handlers.HandleCallgraphRequest, map[string]interface{}{
+		"moduleArgs": []string{"../fixtures/kinds"},
+		"algo":       "rta",
+		"nointer":    false,
+		"symbol":     "main.main",
+	}, args)
+}
+
+// kindsEdges returns the kind of each edge, keyed "caller -> callee" without package paths
+func kindsEdges(t *testing.T, args map[string]interface{}) map[string]string {
+	t.Helper()
+	args["output_format"] = "json"
+	result := kindsRequest(t, args)
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	var resp handlers.MCPCallgraphResponse
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	strip := strings.NewReplacer("callgraph-mcp/tests/fixtures/kinds.", "")
+	edges := make(map[string]string)
+	for _, e := range resp.Graph.Edges {
+		edges[strip.Replace(e.Caller)+" -> "+strip.Replace(e.Callee)] = e.Kind
+	}
+	return edges
+}
+
+func TestEdgeKinds(t *testing.T) {
+	edges := kindsEdges(t, map[string]interface{}{})
+	want := map[string]string{
+		"main -> direct":    "static",
+		"main -> (job).Run": "interface",
+		"call -> main$1":    "closure",
+		"main -> worker":    "go",
+		"main -> cleanup":   "defer",
+		"main$1 -> worker":  "static",
+	}
+	for edge, kind := range want {
+		if edges[edge] != kind {
+			t.Errorf("edge %s: expected kind %q, got %q", edge, kind, edges[edge])
+		}
+	}
+}
+
+func TestEdgeKindsMermaidArrows(t *testing.T) {
+	result := kindsRequest(t, map[string]interface{}{"group": []string{}})
+	text := result.Content[0].(mcp.TextContent).Text
+	ids := make(map[string]string)
+	for _, name := range []string{"main", "direct", "Run", "call", "main$1", "worker", "cleanup"} {
+		id, ok := extractID(text, name)
+		if !ok {
+			t.Fatalf("node %s not found in:\n%s", name, text)
+		}
+		ids[name] = id
+	}
+	for _, want := range []string{
+		ids["main"] + " --> " + ids["direct"],
+		ids["main"] + " -.-> " + ids["Run"],
+		ids["call"] + " -.-> " + ids["main$1"],
+		ids["main"] + " ==> " + ids["worker"],
+		ids["main"] + " -- defer --> " + ids["cleanup"],
+	} {
+		if !strings.Contains(text, want+"\n") {
+			t.Errorf("missing %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestEdgeKindsFilter(t *testing.T) {
+	edges := kindsEdges(t, map[string]interface{}{"edge_kinds": []string{"go", "defer"}})
+	if len(edges) != 2 || edges["main -> worker"] != "go" || edges["main -> cleanup"] != "defer" {
+		t.Errorf("expected only the go and defer edges, got %v", edges)
+	}
+
+	result := kindsRequest(t, map[string]interface{}{"edge_kinds": []string{"goroutine"}})
+	text := result.Content[0].(mcp.TextContent).Text
+	if !result.IsError || !strings.Contains(text, `invalid edge_kinds value "goroutine"`) {
+		t.Errorf("expected an invalid edge_kinds error, got %q", text)
+	}
+}
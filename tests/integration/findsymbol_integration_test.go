@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+const symbolsPkg = "callgraph-mcp/tests/fixtures/symbols"
+
+func findSymbol(t *testing.T, args map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+	return callTool(t, "findSymbol", handlers.HandleFindSymbolRequest, map[string]interface{}{
+		"moduleArgs": []string{"../fixtures/symbols"},
+		"algo":       "static",
+	}, args)
+}
+
+func TestFindSymbolRanksCandidates(t *testing.T) {
+	result := findSymbol(t, map[string]interface{}{"query": "handle", "output_format": "json"})
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	var resp handlers.MCPFindSymbolResponse
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("output is not a valid MCPFindSymbolResponse: %v\n%s", err, text)
+	}
+	if len(resp.Symbols) < 2 {
+		t.Fatalf("expected both Handle methods, got %+v", resp.Symbols)
+	}
+	want := []string{"(*" + symbolsPkg + ".Server).Handle", "(" + symbolsPkg + ".Client).Handle"}
+	for i, name := range want {
+		s := resp.Symbols[i]
+		if s.Name != name {
+			t.Errorf("candidate %d: expected %s, got %s", i, name, s.Name)
+		}
+		if s.ReceiverType == nil || s.Signature != "func()" || s.File != "main.go" || s.Line == 0 || !s.Exported {
+			t.Errorf("candidate %s is missing details: %+v", s.Name, s)
+		}
+	}
+	for _, s := range resp.Symbols {
+		if strings.HasPrefix(s.PackagePath, "fmt") {
+			t.Errorf("std function returned with nostd: %s", s.Name)
+		}
+	}
+}
+
+func TestFindSymbolFuzzySubsequence(t *testing.T) {
+	result := findSymbol(t, map[string]interface{}{"query": "srvlog"})
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError || !strings.Contains(text, "(*"+symbolsPkg+".Server).log") {
+		t.Fatalf("expected Server.log for fuzzy query, got %q", text)
+	}
+}
+
+func TestSymbolAmbiguousListsCandidates(t *testing.T) {
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "callHierarchy",
+			Arguments: map[string]interface{}{
+				"moduleArgs": []string{"../fixtures/symbols"},
+				"algo":       "static",
+				"nointer":    false,
+				"symbol":     "Handle",
+			},
+		},
+	}
+	result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !result.IsError || !strings.Contains(text, "ambiguous symbol") {
+		t.Fatalf("expected ambiguity error, got %q", text)
+	}
+	for _, want := range []string{"(" + symbolsPkg + ".Client).Handle", "(*" + symbolsPkg + ".Server).Handle", "main.go:"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("ambiguity error missing %q:\n%s", want, text)
+		}
+	}
+
+	// The fully qualified name from the candidate list resolves uniquely
+	request.Params.Arguments.(map[string]interface{})["symbol"] = "(" + symbolsPkg + ".Client).Handle"
+	result, err = handlers.HandleCallgraphRequest(context.Background(), request)
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	text = result.Content[0].(mcp.TextContent).Text
+	if result.IsError || !strings.Contains(text, "send") {
+		t.Fatalf("expected Client.Handle traversal, got %q", text)
+	}
+}
+
+func symbolTraversal(t *testing.T, symbol string) (string, bool) {
+	t.Helper()
+	result := callTool(t, "callHierarchy", handlers.HandleCallgraphRequest, map[string]interface{}{
+		"moduleArgs": []string{"../fixtures/symbols"},
+		"algo":       "static",
+		"nointer":    false,
+		"direction":  "both",
+	}, map[string]interface{}{"symbol": symbol})
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestSymbolMethodSpellings(t *testing.T) {
+	for _, symbol := range []string{
+		"Server.Handle",
+		"(*Server).Handle",
+		"main.Server.Handle",
+		"main.(*Server).Handle",
+		symbolsPkg + ".Server.Handle",
+		symbolsPkg + ".(*Server).Handle",
+		"(*" + symbolsPkg + ".Server).Handle",
+	} {
+		text, isErr := symbolTraversal(t, symbol)
+		if isErr || !strings.Contains(text, "log") {
+			t.Errorf("%s: expected Server.Handle traversal, got %q", symbol, text)
+		}
+	}
+}
+
+func TestSymbolClosuresAndGenerics(t *testing.T) {
+	for symbol, want := range map[string]string{
+		"main.main$1":                   "send",
+		"Stack.Push":                    "Push",
+		"Stack[int].Push":               "Push",
+		symbolsPkg + ".Map[int string]": "Map",
+	} {
+		text, isErr := symbolTraversal(t, symbol)
+		if isErr || !strings.Contains(text, want) {
+			t.Errorf("%s: expected traversal containing %s, got %q", symbol, want, text)
+		}
+	}
+
+	// The generic body and its instantiation are both candidates, not one picked silently
+	text, isErr := symbolTraversal(t, "main.Map")
+	if !isErr || !strings.Contains(text, "ambiguous") || !strings.Contains(text, symbolsPkg+".Map  ") || !strings.Contains(text, symbolsPkg+".Map[int string]  ") {
+		t.Errorf("expected main.Map to be ambiguous between the generic body and its instantiation, got %q", text)
+	}
+}
+
+func TestSymbolPromotedMethod(t *testing.T) {
+	text, isErr := symbolTraversal(t, "Server.Close")
+	if !isErr || !strings.Contains(text, "promoted") || !strings.Contains(text, "(*"+symbolsPkg+".Base).Close") {
+		t.Fatalf("expected promoted method error naming Base.Close, got %q", text)
+	}
+}
@@ -0,0 +1,156 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+const layered = "callgraph-mcp/tests/fixtures/layered"
+
+// layeredGraph renders the whole layered fixture at the given granularity
+func layeredGraph(t *testing.T, granularity, format string) *mcp.CallToolResult {
+	t.Helper()
+	return callTool(t, "callHierarchy", handlers.HandleCallgraphRequest, map[string]interface{}{
+		"moduleArgs": []string{"../fixtures/layered"},
+		"nointer":    false,
+		"max_dep":    0,
+	}, map[string]interface{}{"granularity": granularity, "output_format": format})
+}
+
+// layeredEdges returns the aggregated nodes (ID to members) and edges (to call counts)
+func layeredEdges(t *testing.T, granularity string) (map[string]int, map[string]int) {
+	t.Helper()
+	result := layeredGraph(t, granularity, "json")
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	var resp handlers.MCPCallgraphResponse
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if resp.Filters.Granularity != granularity {
+		t.Errorf("expected granularity %q in filters, got %q", granularity, resp.Filters.Granularity)
+	}
+	strip := strings.NewReplacer(layered+"/", "", layered, "main")
+	nodes := make(map[string]int)
+	for _, n := range resp.Graph.Nodes {
+		nodes[strip.Replace(n.ID)] = n.Members
+	}
+	edges := make(map[string]int)
+	for _, e := range resp.Graph.Edges {
+		edges[strip.Replace(e.Caller)+" -> "+strip.Replace(e.Callee)] = e.Count
+	}
+	return nodes, edges
+}
+
+func TestGranularityPkg(t *testing.T) {
+	nodes, edges := layeredEdges(t, "pkg")
+	wantNodes := map[string]int{"main": 1, "service": 3, "store": 3}
+	if len(nodes) != len(wantNodes) {
+		t.Errorf("expected nodes %v, got %v", wantNodes, nodes)
+	}
+	for id, members := range wantNodes {
+		if nodes[id] != members {
+			t.Errorf("node %s: expected %d members, got %d", id, members, nodes[id])
+		}
+	}
+	// New and two Create calls; Open and two Put calls. Calls within a package are dropped.
+	wantEdges := map[string]int{"main -> service": 3, "service -> store": 3}
+	if len(edges) != len(wantEdges) || edges["main -> service"] != 3 || edges["service -> store"] != 3 {
+		t.Errorf("expected edges %v, got %v", wantEdges, edges)
+	}
+
+	result := layeredGraph(t, "pkg", "mermaid")
+	mermaid := result.Content[0].(mcp.TextContent).Text
+	if strings.Contains(mermaid, "subgraph") {
+		t.Errorf("package nodes should not be grouped:\n%s", mermaid)
+	}
+	mainID, _ := extractID(mermaid, layered)
+	serviceID, _ := extractID(mermaid, layered+"/service")
+	if !strings.Contains(mermaid, mainID+" -- 3 calls --> "+serviceID+"\n") || !strings.Contains(mermaid, "3 funcs") {
+		t.Errorf("expected labeled aggregated edges and member counts:\n%s", mermaid)
+	}
+}
+
+func TestGranularityType(t *testing.T) {
+	nodes, edges := layeredEdges(t, "type")
+	for _, id := range []string{"main", "service", "service.Service", "store", "store.Store"} {
+		if _, ok := nodes[id]; !ok {
+			t.Errorf("missing node %s in %v", id, nodes)
+		}
+	}
+	want := map[string]int{
+		"main -> service":                1, // New
+		"main -> service.Service":        2, // Create twice
+		"service -> store":               1, // Open
+		"service.Service -> store.Store": 2, // Put twice
+	}
+	if len(edges) != len(want) {
+		t.Errorf("expected edges %v, got %v", want, edges)
+	}
+	for edge, count := range want {
+		if edges[edge] != count {
+			t.Errorf("edge %s: expected %d calls, got %d", edge, count, edges[edge])
+		}
+	}
+}
+
+func TestGranularityInvalid(t *testing.T) {
+	result := layeredGraph(t, "file", "json")
+	if text := result.Content[0].(mcp.TextContent).Text; !result.IsError || !strings.Contains(text, `invalid granularity "file"`) {
+		t.Errorf("expected an invalid granularity error, got %q", text)
+	}
+}
+
+func TestGranularityEdgeKinds(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"go.mod":         "module example.com/mixed\n\ngo 1.21\n",
+		"main.go":        "package main\n\nimport (\n\t\"example.com/mixed/lib\"\n\t\"example.com/mixed/other\"\n)\n\nfunc main() {\n\tlib.Do()\n\tgo lib.Do()\n\tother.A()\n\tother.B()\n}\n",
+		"lib/lib.go":     "package lib\n\nfunc Do() {}\n",
+		"other/other.go": "package other\n\nfunc A() {}\n\nfunc B() {}\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := handlers.HandleCallgraphRequest(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: map[string]interface{}{
+			"dir":           dir,
+			"moduleArgs":    []string{"./..."},
+			"algo":          "static",
+			"nointer":       false,
+			"granularity":   "pkg",
+			"output_format": "json",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	var resp handlers.MCPCallgraphResponse
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, text)
+	}
+	kinds := make(map[string]string)
+	for _, e := range resp.Graph.Edges {
+		kinds[strings.TrimPrefix(e.Caller, "example.com/mixed/")+" -> "+strings.TrimPrefix(e.Callee, "example.com/mixed/")] = e.Kind
+	}
+	// A direct call and a goroutine merge into a mixed edge; two direct calls stay static
+	if kinds["example.com/mixed -> lib"] != handlers.EdgeKindMixed || kinds["example.com/mixed -> other"] != handlers.EdgeKindStatic {
+		t.Errorf("expected a mixed edge to lib and a static one to other, got %v", kinds)
+	}
+}
@@ -0,0 +1,171 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"callgraph-mcp/handlers"
+)
+
+// freeAddr returns a loopback address with a port nobody listens on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestStreamableHTTPRoundTrip(t *testing.T) {
+	mcpServer := server.NewMCPServer("callgraph-mcp", "test", server.WithLogging())
+	mcpServer.AddTool(mcp.Tool{
+		Name:        "callHierarchy",
+		InputSchema: mcp.ToolInputSchema{Type: "object"},
+	}, handlers.HandleCallgraphRequest)
+
+	// Wired like MCP_TRANSPORT=http with MCP_HTTP_PATH=team/mcp/ and MCP_AUTH_TOKEN=s3cret
+	path, err := handlers.HTTPEndpointPath("team/mcp/")
+	if err != nil || path != "/team/mcp" {
+		t.Fatalf("expected the normalized path /team/mcp, got %q (%v)", path, err)
+	}
+	addr := freeAddr(t)
+	srv := &http.Server{Addr: addr}
+	httpServer := server.NewStreamableHTTPServer(mcpServer, server.WithEndpointPath(path), server.WithStreamableHTTPServer(srv))
+	srv.Handler = handlers.NewHTTPHandler(httpServer, path, []string{"s3cret"})
+	errc := make(chan error, 1)
+	go func() { errc <- httpServer.Start(addr) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		select {
+		case err := <-errc:
+			t.Fatalf("Start failed: %v", err)
+		case <-ctx.Done():
+			t.Fatalf("server did not start: %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	post := func(path, token string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, "http://"+addr+path, strings.NewReader("{}"))
+		if err != nil {
+			t.Fatalf("NewRequest failed: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	// Only the configured path is served, and only with the token
+	if code := post("/mcp", "s3cret"); code != http.StatusNotFound {
+		t.Errorf("expected 404 outside the endpoint path, got %d", code)
+	}
+	if code := post("/team/mcp/sub", "s3cret"); code != http.StatusNotFound {
+		t.Errorf("expected 404 below the endpoint path, got %d", code)
+	}
+	if code := post("/team/mcp", ""); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", code)
+	}
+
+	c, err := client.NewStreamableHttpClient("http://"+addr+"/team/mcp",
+		transport.WithHTTPHeaders(map[string]string{"Authorization": "Bearer s3cret"}))
+	if err != nil {
+		t.Fatalf("NewStreamableHttpClient failed: %v", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if c.GetSessionId() == "" {
+		t.Error("expected a session ID from the streamable HTTP server")
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "callHierarchy"
+	request.Params.Arguments = map[string]any{
+		"moduleArgs": []string{"../fixtures/chain"},
+		"algo":       "static",
+		"symbol":     "main.main",
+		"nointer":    false,
+	}
+	result, err := c.CallTool(ctx, request)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if result.IsError || len(result.Content) == 0 {
+		t.Fatalf("unexpected result: %+v", result.Content)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.HasPrefix(text, "flowchart LR\n") || !strings.Contains(text, "main<br/>") {
+		t.Errorf("unexpected callHierarchy output over HTTP:\n%s", text)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	// Graceful shutdown, as on SIGTERM: Start returns once the server is closed
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	select {
+	case err := <-errc:
+		if !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("expected http.ErrServerClosed, got %v", err)
+		}
+	case <-shutdownCtx.Done():
+		t.Fatal("Start did not return after Shutdown")
+	}
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Error("expected the listener to be closed after shutdown")
+	}
+}
+
+func TestHTTPEndpointPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"":           "/mcp",
+		"mcp":        "/mcp",
+		"/team/mcp/": "/team/mcp",
+		"/":          "/",
+	} {
+		if got, err := handlers.HTTPEndpointPath(in); err != nil || got != want {
+			t.Errorf("HTTPEndpointPath(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"POST /mcp", "/mcp/{id}", "/mcp?x=1"} {
+		if _, err := handlers.HTTPEndpointPath(in); err == nil {
+			t.Errorf("expected HTTPEndpointPath(%q) to fail", in)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+// layeredMetrics runs callgraphMetrics on the layered fixture
+func layeredMetrics(t *testing.T, args map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+	result := callTool(t, "callgraphMetrics", handlers.HandleMetricsRequest, map[string]interface{}{
+		"moduleArgs": []string{"../fixtures/layered/..."},
+	}, args)
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.Content[0].(mcp.TextContent).Text)
+	}
+	return result
+}
+
+func TestMetricsFunctions(t *testing.T) {
+	result := layeredMetrics(t, map[string]interface{}{"output_format": "json", "top_n": 3})
+	var resp handlers.MCPMetricsResponse
+	if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &resp); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if resp.Total != 7 || len(resp.Functions) != 3 || resp.SortBy != "betweenness" {
+		t.Fatalf("expected the top 3 of 7 functions by betweenness, got %+v", resp)
+	}
+
+	// Create lies on main's paths to Put, log and index: 3 of the 6*5 ordered pairs
+	create := resp.Functions[0]
+	if create.Func != "(*"+layered+"/service.Service).Create" {
+		t.Fatalf("expected Create to be the top hub, got %+v", resp.Functions)
+	}
+	if create.FanIn != 1 || create.FanOut != 2 || create.Callers != 1 || create.Callees != 3 || create.Depth == nil || *create.Depth != 1 || create.Betweenness != 0.1 {
+		t.Errorf("unexpected Create metrics: %+v", create)
+	}
+	if put := resp.Functions[1]; !strings.HasSuffix(put.Func, "store.Store).Put") || put.Betweenness != 0.0667 || *put.Depth != 2 {
+		t.Errorf("expected Put second, got %+v", put)
+	}
+
+	want := map[string][3]float64{
+		layered + "/service": {1, 1, 0.5},
+		layered:              {0, 1, 1},
+		layered + "/store":   {1, 0, 0},
+	}
+	if len(resp.Packages) != 3 || resp.Packages[0].Path != layered+"/service" {
+		t.Fatalf("expected service to be the most coupled package, got %+v", resp.Packages)
+	}
+	for _, p := range resp.Packages {
+		w := want[p.Path]
+		if float64(p.Afferent) != w[0] || float64(p.Efferent) != w[1] || p.Instability != w[2] {
+			t.Errorf("package %s: expected Ca/Ce/I %v, got %+v", p.Path, w, p)
+		}
+	}
+}
+
+func TestMetricsMarkdown(t *testing.T) {
+	result := layeredMetrics(t, map[string]interface{}{"sort_by": "fan_out"})
+	if len(result.Content) != 2 {
+		t.Fatalf("expected Markdown and JSON by default, got %d contents", len(result.Content))
+	}
+	md := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(md, "## Top 7 of 7 functions by fan_out") || !strings.Contains(md, "| 2 | `"+layered+".main` | 0 | 2 | 0 | 6 | 0 |") {
+		t.Errorf("unexpected function table:\n%s", md)
+	}
+	if !strings.Contains(md, "| `"+layered+"/store` | 3 | 1 | 0 | 0.00 |") {
+		t.Errorf("unexpected package table:\n%s", md)
+	}
+
+	bad, err := handlers.HandleMetricsRequest(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "callgraphMetrics", Arguments: map[string]interface{}{
+			"moduleArgs": []string{"../fixtures/layered/..."},
+			"sort_by":    "depth",
+		}},
+	})
+	if err != nil || !bad.IsError || !strings.Contains(bad.Content[0].(mcp.TextContent).Text, `invalid sort_by "depth"`) {
+		t.Errorf("expected a sort_by error, got %+v", bad)
+	}
+}
@@ -0,0 +1,294 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+func TestCallgraphJSONOutput(t *testing.T) {
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "callHierarchy",
+			Arguments: map[string]interface{}{
+				"moduleArgs":    []string{"../fixtures/simple"},
+				"algo":          "static",
+				"nostd":         true,
+				"nointer":       false,
+				"symbol":        "main.main",
+				"direction":     "downstream",
+				"output_format": "json",
+			},
+		},
+	}
+
+	result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	if result.IsError || len(result.Content) != 1 {
+		t.Fatalf("expected a single JSON content, got %+v", result.Content)
+	}
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatal("result content is not TextContent")
+	}
+
+	var resp handlers.MCPCallgraphResponse
+	if err := json.Unmarshal([]byte(textContent.Text), &resp); err != nil {
+		t.Fatalf("output is not a valid MCPCallgraphResponse: %v\n%s", err, textContent.Text)
+	}
+	if resp.Algorithm != "static" {
+		t.Errorf("expected algorithm static, got %q", resp.Algorithm)
+	}
+	if !resp.Filters.NoStd || resp.Filters.NoInter {
+		t.Errorf("unexpected filters: %+v", resp.Filters)
+	}
+	if resp.Filters.Symbol != "main.main" || resp.Filters.Direction != "downstream" {
+		t.Errorf("unexpected traversal filters: %+v", resp.Filters)
+	}
+	if resp.Stats.NodeCount != len(resp.Graph.Nodes) || resp.Stats.EdgeCount != len(resp.Graph.Edges) {
+		t.Errorf("stats %+v do not match graph (%d nodes, %d edges)", resp.Stats, len(resp.Graph.Nodes), len(resp.Graph.Edges))
+	}
+
+	funcs := make(map[string]handlers.MCPCallgraphNode)
+	for _, n := range resp.Graph.Nodes {
+		funcs[n.Func] = n
+	}
+	for _, name := range []string{"main", "hello", "goodbye", "worker"} {
+		n, ok := funcs[name]
+		if !ok {
+			t.Fatalf("node %s missing from JSON graph: %+v", name, resp.Graph.Nodes)
+		}
+		if n.File != "main.go" || n.Line == 0 {
+			t.Errorf("node %s has no position: %+v", name, n)
+		}
+	}
+	for _, e := range resp.Graph.Edges {
+		if e.File == "" || e.Line == 0 {
+			t.Errorf("edge %s -> %s has no call site", e.Caller, e.Callee)
+		}
+	}
+}
+
+func TestCallgraphBothOutput(t *testing.T) {
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "callHierarchy",
+			Arguments: map[string]interface{}{
+				"moduleArgs":    []string{"../fixtures/simple"},
+				"algo":          "static",
+				"nostd":         true,
+				"output_format": "both",
+			},
+		},
+	}
+
+	result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	if result.IsError || len(result.Content) != 2 {
+		t.Fatalf("expected Mermaid and JSON contents, got %+v", result.Content)
+	}
+	mermaid := result.Content[0].(mcp.TextContent).Text
+	if !strings.HasPrefix(mermaid, "flowchart ") {
+		t.Fatalf("first content is not Mermaid: %q", mermaid)
+	}
+	var resp handlers.MCPCallgraphResponse
+	if err := json.Unmarshal([]byte(result.Content[1].(mcp.TextContent).Text), &resp); err != nil {
+		t.Fatalf("second content is not JSON: %v", err)
+	}
+}
+
+func TestCallgraphInvalidOutputFormat(t *testing.T) {
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "callHierarchy",
+			Arguments: map[string]interface{}{
+				"moduleArgs":    []string{"../fixtures/simple"},
+				"output_format": "yaml",
+			},
+		},
+	}
+
+	result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("expected error for invalid output_format")
+	}
+}
+
+func TestCallgraphDotOutput(t *testing.T) {
+	for _, args := range []map[string]interface{}{
+		{"group": []string{"pkg", "type"}},
+		{"group": []string{"pkg"}, "symbol": "main.main", "direction": "downstream"},
+	} {
+		args["moduleArgs"] = []string{"../fixtures/simple"}
+		args["algo"] = "static"
+		args["nostd"] = true
+		args["nointer"] = false
+		args["output_format"] = "dot"
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: args},
+		}
+
+		result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+		if err != nil {
+			t.Fatalf("HandleCallgraphRequest failed: %v", err)
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		if result.IsError || !strings.HasPrefix(text, "digraph ") {
+			t.Fatalf("expected DOT output, got %q", text)
+		}
+		for _, want := range []string{
+			`label="pkg:callgraph-mcp/tests/fixtures/simple"`,
+			`label="hello"`,
+			`main.go:`,
+			"->",
+		} {
+			if !strings.Contains(text, want) {
+				t.Errorf("DOT output missing %q:\n%s", want, text)
+			}
+		}
+	}
+}
+
+func TestCallgraphSVGOutput(t *testing.T) {
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "callHierarchy",
+			Arguments: map[string]interface{}{
+				"moduleArgs":    []string{"../fixtures/simple"},
+				"algo":          "static",
+				"nostd":         true,
+				"nointer":       false,
+				"symbol":        "main.main",
+				"output_format": "svg",
+			},
+		},
+	}
+
+	result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError || !strings.HasPrefix(text, "<svg ") || !strings.HasSuffix(strings.TrimSpace(text), "</svg>") {
+		t.Fatalf("expected SVG document, got %q", text)
+	}
+	for _, want := range []string{">hello</text>", ">worker</text>", "marker-end=\"url(#arrow)\""} {
+		if !strings.Contains(text, want) {
+			t.Errorf("SVG output missing %q", want)
+		}
+	}
+}
+
+func TestCallgraphEdgeCallSites(t *testing.T) {
+	arguments := map[string]interface{}{
+		"moduleArgs":    []string{"../fixtures/simple"},
+		"algo":          "static",
+		"nointer":       false,
+		"symbol":        "main.main",
+		"output_format": "both",
+		"call_counts":   true,
+	}
+	result, err := handlers.HandleCallgraphRequest(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: arguments},
+	})
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	if result.IsError || len(result.Content) != 2 {
+		t.Fatalf("expected Mermaid and JSON contents, got %+v", result.Content)
+	}
+
+	var resp handlers.MCPCallgraphResponse
+	if err := json.Unmarshal([]byte(result.Content[1].(mcp.TextContent).Text), &resp); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	edges := make(map[string]handlers.MCPCallgraphEdge)
+	for _, e := range resp.Graph.Edges {
+		edges[e.Callee[strings.LastIndex(e.Callee, ".")+1:]] = e
+	}
+
+	// hello() is called once unconditionally and once in the else branch
+	hello := edges["hello"]
+	if hello.Count != 2 || len(hello.Sites) != 2 {
+		t.Fatalf("expected two call sites for main -> hello, got %+v", hello)
+	}
+	if hello.Sites[0].Line != 22 || hello.Sites[1].Line != 28 || hello.Sites[0].Column == 0 {
+		t.Errorf("unexpected call sites %+v", hello.Sites)
+	}
+	if hello.File != hello.Sites[0].File || hello.Line != hello.Sites[0].Line {
+		t.Errorf("primary site %s:%d should be the first site", hello.File, hello.Line)
+	}
+	if goodbye := edges["goodbye"]; goodbye.Count != 1 || len(goodbye.Sites) != 1 || goodbye.Sites[0].Kind != "static" {
+		t.Errorf("expected a single static call site for main -> goodbye, got %+v", goodbye)
+	}
+	if worker := edges["worker"]; worker.Count != 1 || worker.Sites[0].Kind != "go" {
+		t.Errorf("expected a single go call site for main -> worker, got %+v", worker)
+	}
+
+	mermaid := result.Content[0].(mcp.TextContent).Text
+	mainID, _ := extractID(mermaid, "main")
+	helloID, _ := extractID(mermaid, "hello")
+	goodbyeID, _ := extractID(mermaid, "goodbye")
+	if !strings.Contains(mermaid, mainID+" -- 2 calls --> "+helloID+"\n") {
+		t.Errorf("expected a call count label on main -> hello:\n%s", mermaid)
+	}
+	if !strings.Contains(mermaid, mainID+" --> "+goodbyeID+"\n") {
+		t.Errorf("single calls should not be labeled:\n%s", mermaid)
+	}
+}
+
+func TestCallgraphSVGClusters(t *testing.T) {
+	for _, tc := range []struct {
+		group      []string
+		want, lack []string
+	}{
+		{[]string{"pkg", "type"}, []string{"<title>pkg:callgraph-mcp/tests/fixtures/kinds</title>", "<title>type:func</title>", "<title>type:callgraph-mcp/tests/fixtures/kinds.job</title>"}, nil},
+		{[]string{"type"}, []string{"<title>type:func</title>", "<title>type:callgraph-mcp/tests/fixtures/kinds.job</title>"}, []string{"<title>pkg:"}},
+		{[]string{"pkg"}, []string{"<title>pkg:callgraph-mcp/tests/fixtures/kinds</title>"}, []string{"<title>type:"}},
+	} {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "callHierarchy",
+				Arguments: map[string]interface{}{
+					"moduleArgs":    []string{"../fixtures/kinds"},
+					"algo":          "rta",
+					"nointer":       false,
+					"symbol":        "main.main",
+					"group":         tc.group,
+					"output_format": "svg",
+				},
+			},
+		}
+
+		result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+		if err != nil {
+			t.Fatalf("HandleCallgraphRequest failed: %v", err)
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		if result.IsError || !strings.HasPrefix(text, "<svg ") {
+			t.Fatalf("expected SVG document, got %q", text)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(text, `<g class="cluster">`+want) {
+				t.Errorf("group %v: SVG output missing cluster %q:\n%s", tc.group, want, text)
+			}
+		}
+		for _, lack := range tc.lack {
+			if strings.Contains(text, lack) {
+				t.Errorf("group %v: SVG output should not contain %q:\n%s", tc.group, lack, text)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+func positionTraversal(t *testing.T, args map[string]interface{}) (string, bool) {
+	t.Helper()
+	result := callTool(t, "callHierarchy", handlers.HandleCallgraphRequest, map[string]interface{}{
+		"moduleArgs":    []string{"../fixtures/symbols"},
+		"algo":          "static",
+		"nointer":       false,
+		"output_format": "json",
+	}, args)
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestPositionResolvesEnclosingFunction(t *testing.T) {
+	for _, tc := range []struct {
+		file   string
+		line   int
+		column int
+		want   string
+	}{
+		{"../fixtures/symbols/main.go", 16, 0, "(*" + symbolsPkg + ".Server).Handle"},
+		{"symbols/main.go", 15, 0, "(*" + symbolsPkg + ".Server).Handle"},
+		// Closure body and the line declaring it
+		{"../fixtures/symbols/main.go", 56, 0, symbolsPkg + ".main$1"},
+		{"../fixtures/symbols/main.go", 55, 0, symbolsPkg + ".main$1"},
+		// A column before the closure selects the enclosing main
+		{"../fixtures/symbols/main.go", 55, 2, symbolsPkg + ".main"},
+		{"../fixtures/symbols/main.go", 56, 3, symbolsPkg + ".main$1"},
+	} {
+		text, isErr := positionTraversal(t, map[string]interface{}{"file": tc.file, "line": tc.line, "column": tc.column})
+		if isErr {
+			t.Errorf("%s:%d:%d: unexpected error: %s", tc.file, tc.line, tc.column, text)
+			continue
+		}
+		var resp handlers.MCPCallgraphResponse
+		if err := json.Unmarshal([]byte(text), &resp); err != nil {
+			t.Fatalf("output is not a valid MCPCallgraphResponse: %v", err)
+		}
+		if resp.Filters.Symbol != tc.want {
+			t.Errorf("%s:%d:%d: expected start %s, got %s", tc.file, tc.line, tc.column, tc.want, resp.Filters.Symbol)
+		}
+	}
+}
+
+func TestPositionErrors(t *testing.T) {
+	for _, tc := range []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"file": "main.go"}, "line is required"},
+		{map[string]interface{}{"file": "main.go", "line": 3, "symbol": "main.main"}, "mutually exclusive"},
+		{map[string]interface{}{"file": "missing.go", "line": 3}, "file not found"},
+		{map[string]interface{}{"file": "../fixtures/symbols/main.go", "line": 3}, "no function"},
+		{map[string]interface{}{"file": "../fixtures/symbols/main.go", "line": 999}, "out of range"},
+		{map[string]interface{}{"file": "../fixtures/symbols/main.go", "line": 3, "column": -1}, "invalid column -1"},
+	} {
+		text, isErr := positionTraversal(t, tc.args)
+		if !isErr || !strings.Contains(text, tc.want) {
+			t.Errorf("%v: expected error containing %q, got %q", tc.args, tc.want, text)
+		}
+	}
+}
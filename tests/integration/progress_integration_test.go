@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"callgraph-mcp/handlers"
+)
+
+func TestProgressAndLoggingNotifications(t *testing.T) {
+	mcpServer := server.NewMCPServer("callgraph-mcp", "test", server.WithLogging())
+	mcpServer.AddTool(mcp.Tool{
+		Name:        "callHierarchy",
+		InputSchema: mcp.ToolInputSchema{Type: "object"},
+	}, handlers.HandleCallgraphRequest)
+	ts := server.NewTestServer(mcpServer)
+	defer ts.Close()
+
+	c, err := client.NewSSEMCPClient(ts.URL + "/sse")
+	if err != nil {
+		t.Fatalf("NewSSEMCPClient failed: %v", err)
+	}
+	defer c.Close()
+
+	var mu sync.Mutex
+	var progress []map[string]any
+	var logs []string
+	c.OnNotification(func(n mcp.JSONRPCNotification) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch n.Method {
+		case "notifications/progress":
+			progress = append(progress, n.Params.AdditionalFields)
+		case "notifications/message":
+			if data, ok := n.Params.AdditionalFields["data"].(string); ok {
+				logs = append(logs, data)
+			}
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	setLevel := mcp.SetLevelRequest{}
+	setLevel.Params.Level = mcp.LoggingLevelInfo
+	if err := c.SetLevel(ctx, setLevel); err != nil {
+		t.Fatalf("SetLevel failed: %v", err)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "callHierarchy"
+	request.Params.Arguments = map[string]any{
+		"moduleArgs": []string{"../fixtures/chain"},
+		"algo":       "static",
+		"symbol":     "main.main",
+		"refresh":    true,
+	}
+	request.Params.Meta = &mcp.Meta{ProgressToken: "analysis-1"}
+	result, err := c.CallTool(ctx, request)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error: %+v", result.Content)
+	}
+
+	// Notifications travel on the SSE stream and may trail the response
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		done := len(progress) >= 5
+		mu.Unlock()
+		if done || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(progress) != 5 {
+		t.Fatalf("expected 5 progress notifications, got %d: %v", len(progress), progress)
+	}
+	for i, p := range progress {
+		if p["progressToken"] != "analysis-1" || p["progress"] != float64(i+1) || p["total"] != float64(5) {
+			t.Errorf("unexpected progress notification %d: %v", i, p)
+		}
+	}
+	for i, want := range []string{"loading packages", "building SSA", "computing callgraph", "filtering", "rendering"} {
+		if msg, _ := progress[i]["message"].(string); !strings.Contains(msg, want) {
+			t.Errorf("progress %d: expected message about %q, got %q", i+1, want, msg)
+		}
+	}
+	if !strings.Contains(strings.Join(logs, "\n"), "callgraph resolved with") {
+		t.Errorf("analysis log lines were not forwarded: %v", logs)
+	}
+}
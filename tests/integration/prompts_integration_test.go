@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"callgraph-mcp/handlers"
+)
+
+func TestPrompts(t *testing.T) {
+	chain, err := filepath.Abs("../fixtures/chain")
+	if err != nil {
+		t.Fatalf("Abs failed: %v", err)
+	}
+
+	mcpServer := server.NewMCPServer("callgraph-mcp", "test", server.WithResourceCapabilities(false, true))
+	mcpServer.AddPrompt(mcp.NewPrompt(handlers.PromptExplainArchitecture), handlers.HandleExplainArchitecturePrompt)
+	mcpServer.AddPrompt(mcp.NewPrompt(handlers.PromptChangeImpact), handlers.HandleChangeImpactPrompt)
+	mcpServer.AddPrompt(mcp.NewPrompt(handlers.PromptTraceRequest), handlers.HandleTraceRequestPrompt)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	c, err := client.NewInProcessClient(mcpServer)
+	if err != nil {
+		t.Fatalf("NewInProcessClient failed: %v", err)
+	}
+	defer c.Close()
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	listed, err := c.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil || len(listed.Prompts) != 3 {
+		t.Fatalf("expected 3 prompts, got %+v (%v)", listed, err)
+	}
+
+	getPrompt := func(name string, args map[string]string) (string, error) {
+		t.Helper()
+		request := mcp.GetPromptRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		result, err := c.GetPrompt(ctx, request)
+		if err != nil {
+			return "", err
+		}
+		if len(result.Messages) != 1 || result.Messages[0].Role != mcp.RoleUser {
+			t.Fatalf("%s: expected one user message, got %+v", name, result.Messages)
+		}
+		return result.Messages[0].Content.(mcp.TextContent).Text, nil
+	}
+
+	text, err := getPrompt(handlers.PromptChangeImpact, map[string]string{"dir": chain, "moduleArgs": ".", "symbol": "main.store"})
+	if err != nil {
+		t.Fatalf("GetPrompt failed: %v", err)
+	}
+	// The upstream traversal of store is embedded, with its graph resource
+	for _, want := range []string{"I want to change main.store", "```mermaid\nflowchart LR\n", "validate", "save", "callgraph://chain/", "/json?symbol=main.store&direction=upstream"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("changeImpact prompt is missing %q:\n%s", want, text)
+		}
+	}
+
+	text, err = getPrompt(handlers.PromptExplainArchitecture, map[string]string{"dir": chain, "moduleArgs": "."})
+	if err != nil {
+		t.Fatalf("GetPrompt failed: %v", err)
+	}
+	if !strings.Contains(text, "package-level callgraph") || !strings.Contains(text, "```mermaid\n") {
+		t.Errorf("unexpected explainArchitecture prompt:\n%s", text)
+	}
+
+	text, err = getPrompt(handlers.PromptTraceRequest, map[string]string{"dir": chain, "moduleArgs": ".", "symbol": "main.handle", "sink": "store"})
+	if err != nil {
+		t.Fatalf("GetPrompt failed: %v", err)
+	}
+	if !strings.Contains(text, "downstream callgraph of main.handle") || !strings.Contains(text, `matching "store"`) {
+		t.Errorf("unexpected traceRequest prompt:\n%s", text)
+	}
+
+	if _, err := getPrompt(handlers.PromptChangeImpact, map[string]string{"dir": chain, "moduleArgs": "."}); err == nil || !strings.Contains(err.Error(), "symbol is required") {
+		t.Errorf("expected a missing symbol error, got %v", err)
+	}
+	if _, err := getPrompt(handlers.PromptTraceRequest, map[string]string{"dir": chain, "symbol": "main.handle", "direction": "sideways"}); err == nil {
+		t.Error("expected an error for an invalid direction")
+	}
+	if _, err := getPrompt(handlers.PromptChangeImpact, map[string]string{"dir": chain, "moduleArgs": ".", "symbol": "main.nosuch"}); err == nil {
+		t.Error("expected an error for an unknown symbol")
+	}
+}
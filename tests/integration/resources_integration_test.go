@@ -0,0 +1,206 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"callgraph-mcp/handlers"
+)
+
+func TestGraphResources(t *testing.T) {
+	chain, err := filepath.Abs("../fixtures/chain")
+	if err != nil {
+		t.Fatalf("Abs failed: %v", err)
+	}
+
+	mcpServer := server.NewMCPServer("callgraph-mcp", "test", server.WithResourceCapabilities(false, true))
+	mcpServer.AddTool(mcp.Tool{
+		Name:        "callHierarchy",
+		InputSchema: mcp.ToolInputSchema{Type: "object"},
+	}, handlers.HandleCallgraphRequest)
+	mcpServer.AddResourceTemplate(mcp.NewResourceTemplate(handlers.GraphResourceTemplate, "Callgraph"), handlers.HandleGraphResource)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	c, err := client.NewInProcessClient(mcpServer)
+	if err != nil {
+		t.Fatalf("NewInProcessClient failed: %v", err)
+	}
+	defer c.Close()
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "callHierarchy"
+	request.Params.Arguments = map[string]any{
+		"dir":        chain,
+		"moduleArgs": []string{"."},
+		"algo":       "static",
+		"symbol":     "main.handle",
+		"nointer":    false,
+	}
+	result, err := c.CallTool(ctx, request)
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %v %+v", err, result)
+	}
+
+	// The tool output links one resource per view
+	links := make(map[string]string)
+	for _, content := range result.Content[1:] {
+		link, ok := content.(mcp.ResourceLink)
+		if !ok {
+			t.Fatalf("expected resource links after the output, got %T", content)
+		}
+		u, err := url.Parse(link.URI)
+		if err != nil {
+			t.Fatalf("invalid resource URI %q: %v", link.URI, err)
+		}
+		links[path.Base(u.Path)] = link.URI
+	}
+	if len(links) != 4 || !strings.HasPrefix(links["json"], "callgraph://chain/") || !strings.HasSuffix(links["json"], "/json?symbol=main.handle&direction=downstream") {
+		t.Fatalf("unexpected resource links: %v", links)
+	}
+
+	listed, err := c.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		t.Fatalf("ListResources failed: %v", err)
+	}
+	found := 0
+	for _, r := range listed.Resources {
+		for _, uri := range links {
+			if r.URI == uri {
+				found++
+			}
+		}
+	}
+	if found != len(links) {
+		t.Errorf("expected the linked resources to be listed, got %+v", listed.Resources)
+	}
+
+	read := func(uri string) (string, error) {
+		t.Helper()
+		readRequest := mcp.ReadResourceRequest{}
+		readRequest.Params.URI = uri
+		result, err := c.ReadResource(ctx, readRequest)
+		if err != nil {
+			return "", err
+		}
+		return result.Contents[0].(mcp.TextResourceContents).Text, nil
+	}
+
+	// The JSON view is the MCPCallgraphResponse of the traversal
+	text, err := read(links["json"])
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	var resp handlers.MCPCallgraphResponse
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("invalid JSON resource: %v", err)
+	}
+	if resp.Filters.Symbol != "main.handle" || resp.Stats.NodeCount != 3 {
+		t.Errorf("unexpected JSON resource: %+v", resp)
+	}
+
+	// The template parameterizes the same graph by symbol and direction
+	base := links["mermaid"][:strings.Index(links["mermaid"], "?")]
+	text, err = read(base + "?symbol=main.store&direction=upstream")
+	if err != nil {
+		t.Fatalf("ReadResource via template failed: %v", err)
+	}
+	if !strings.HasPrefix(text, "flowchart LR\n") || !strings.Contains(text, "save") || !strings.Contains(text, "validate") {
+		t.Errorf("unexpected upstream traversal resource:\n%s", text)
+	}
+
+	// Without symbol the whole graph is returned, here collapsed into packages
+	text, err = read(strings.TrimSuffix(base, "/mermaid") + "/packages")
+	if err != nil {
+		t.Fatalf("ReadResource of packages failed: %v", err)
+	}
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("invalid packages resource: %v", err)
+	}
+	if resp.Filters.Granularity != "pkg" || resp.Stats.NodeCount != 1 {
+		t.Errorf("unexpected packages resource: %+v", resp)
+	}
+
+	// The same graph without symbol has the same hash, and reading it from a
+	// symbol uses the traversal depth default
+	delete(request.Params.Arguments.(map[string]any), "symbol")
+	result, err = c.CallTool(ctx, request)
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %v %+v", err, result)
+	}
+	var jsonURI string
+	for _, content := range result.Content[1:] {
+		if link := content.(mcp.ResourceLink); strings.HasSuffix(link.URI, "/json") {
+			jsonURI = link.URI
+		}
+	}
+	if want := strings.TrimSuffix(base, "/mermaid") + "/json"; jsonURI != want {
+		t.Errorf("expected %s for the graph without symbol, got %s", want, jsonURI)
+	}
+	text, err = read(jsonURI + "?symbol=main.main")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	var traversal handlers.MCPCallgraphResponse
+	if err := json.Unmarshal([]byte(text), &traversal); err != nil {
+		t.Fatalf("invalid JSON resource: %v", err)
+	}
+	if traversal.Filters.MaxDep != 7 {
+		t.Errorf("expected the symbol default max_dep 7, got %+v", traversal.Filters)
+	}
+
+	// Each graph keeps its most recent traversals as resources only; older
+	// ones stay readable through the template
+	graphBase := strings.TrimSuffix(base, "/mermaid")
+	for _, symbol := range []string{"main.main", "main.handle", "main.validate", "main.direct", "main.save"} {
+		for _, direction := range []string{"downstream", "upstream"} {
+			args := request.Params.Arguments.(map[string]any)
+			args["symbol"], args["direction"] = symbol, direction
+			if result, err := c.CallTool(ctx, request); err != nil || result.IsError {
+				t.Fatalf("CallTool failed: %v %+v", err, result)
+			}
+		}
+	}
+	listed, err = c.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		t.Fatalf("ListResources failed: %v", err)
+	}
+	traversals := 0
+	for _, r := range listed.Resources {
+		if strings.HasPrefix(r.URI, graphBase+"/") && strings.Contains(r.URI, "?symbol=") {
+			traversals++
+			if strings.Contains(r.URI, "symbol=main.main&direction=downstream") {
+				t.Errorf("expected the oldest traversal to be removed, got %s", r.URI)
+			}
+		}
+	}
+	if traversals != 8*4 {
+		t.Errorf("expected 8 traversals of 4 views listed, got %d resources", traversals)
+	}
+	if _, err := read(graphBase + "/mermaid?symbol=main.main&direction=downstream"); err != nil {
+		t.Errorf("expected a removed traversal to stay readable: %v", err)
+	}
+
+	if _, err := read("callgraph://chain/000000000000/json"); err == nil {
+		t.Error("expected an error for an unknown graph")
+	}
+	if _, err := read(base + "?symbol=main.store&direction=sideways"); err == nil {
+		t.Error("expected an error for an invalid direction")
+	}
+}
@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+// libraryGraph runs an RTA analysis of the library fixture, returning its
+// text output (empty on error) and the error message, if any
+func libraryGraph(t *testing.T, args map[string]interface{}) (string, string) {
+	t.Helper()
+	result := callTool(t, "callHierarchy", handlers.HandleCallgraphRequest, map[string]interface{}{
+		"moduleArgs": []string{"../fixtures/library"},
+		"algo":       "rta",
+		"nointer":    false,
+		"max_dep":    0,
+	}, args)
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError {
+		return "", text
+	}
+	return text, ""
+}
+
+// libraryEdges returns the JSON response and its edges as "caller -> callee"
+func libraryEdges(t *testing.T, args map[string]interface{}) (handlers.MCPCallgraphResponse, map[string]bool) {
+	t.Helper()
+	args["output_format"] = "json"
+	out, errMsg := libraryGraph(t, args)
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	var resp handlers.MCPCallgraphResponse
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	edges := make(map[string]bool)
+	for _, e := range resp.Graph.Edges {
+		edges[e.Caller+" -> "+e.Callee] = true
+	}
+	return resp, edges
+}
+
+const (
+	putEdge    = "(*callgraph-mcp/tests/fixtures/library.Store).Put -> (*callgraph-mcp/tests/fixtures/library.Store).validate"
+	lookupEdge = "callgraph-mcp/tests/fixtures/library.Lookup -> (*callgraph-mcp/tests/fixtures/library.Store).get"
+	testEdge   = "callgraph-mcp/tests/fixtures/library.TestPut -> callgraph-mcp/tests/fixtures/library.check"
+)
+
+func TestRTALibraryFallback(t *testing.T) {
+	resp, edges := libraryEdges(t, map[string]interface{}{"refresh": true})
+	if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "no main packages") {
+		t.Errorf("expected a fallback warning, got %v", resp.Warnings)
+	}
+	if !edges[putEdge] || !edges[lookupEdge] {
+		t.Errorf("exported API should be analyzed, got edges %v", edges)
+	}
+
+	out, _ := libraryGraph(t, nil)
+	if !strings.HasPrefix(out, "%% warning: no main packages") {
+		t.Errorf("Mermaid output should start with the warning, got:\n%s", out)
+	}
+}
+
+func TestRTARootsMainRequired(t *testing.T) {
+	_, errMsg := libraryGraph(t, map[string]interface{}{"rta_roots": "main"})
+	if !strings.Contains(errMsg, "no main packages") {
+		t.Errorf("expected a no main packages error, got %q", errMsg)
+	}
+}
+
+func TestRTAExplicitRoots(t *testing.T) {
+	resp, edges := libraryEdges(t, map[string]interface{}{"roots": []string{"library.Lookup"}})
+	if len(resp.Warnings) != 0 {
+		t.Errorf("explicit roots should not warn, got %v", resp.Warnings)
+	}
+	if !edges[lookupEdge] || edges[putEdge] {
+		t.Errorf("only Lookup should be reachable, got edges %v", edges)
+	}
+
+	_, errMsg := libraryGraph(t, map[string]interface{}{"roots": []string{"Missing"}})
+	if !strings.Contains(errMsg, "RTA root not found: Missing") {
+		t.Errorf("expected an unknown root error, got %q", errMsg)
+	}
+}
+
+func TestRTATestRoots(t *testing.T) {
+	_, edges := libraryEdges(t, map[string]interface{}{"rta_roots": "tests", "tests": true})
+	if !edges[testEdge] || !edges[putEdge] || edges[lookupEdge] {
+		t.Errorf("only code reached from tests should be analyzed, got edges %v", edges)
+	}
+
+	_, errMsg := libraryGraph(t, map[string]interface{}{"rta_roots": "tests"})
+	if !strings.Contains(errMsg, "requires tests=true") {
+		t.Errorf("expected a tests=true error, got %q", errMsg)
+	}
+}
@@ -2,12 +2,14 @@ package integration
 
 import (
     "context"
+    "encoding/json"
     "fmt"
     "regexp"
     "strings"
     "testing"
 
     "github.com/mark3labs/mcp-go/mcp"
+    "github.com/mark3labs/mcp-go/server"
 
     "callgraph-mcp/handlers"
 )
@@ -24,6 +26,25 @@ func extractID(text string, funcName string) (string, bool) {
     return m[1], true
 }
 
+// Helper: run a tool handler with the fixture arguments, overridden by args
+func callTool(t *testing.T, tool string, handler server.ToolHandlerFunc, fixture, args map[string]interface{}) *mcp.CallToolResult {
+    t.Helper()
+    arguments := make(map[string]interface{}, len(fixture)+len(args))
+    for k, v := range fixture {
+        arguments[k] = v
+    }
+    for k, v := range args {
+        arguments[k] = v
+    }
+    result, err := handler(context.Background(), mcp.CallToolRequest{
+        Params: mcp.CallToolParams{Name: tool, Arguments: arguments},
+    })
+    if err != nil {
+        t.Fatalf("%s failed: %v", tool, err)
+    }
+    return result
+}
+
 func TestSymbolCallsDownstream(t *testing.T) {
     request := mcp.CallToolRequest{
         Params: mcp.CallToolParams{
@@ -233,9 +254,90 @@ func TestSymbolCallsWorkerDownstreamAlgorithms(t *testing.T) {
             if !ok { t.Fatalf("main node ID not found (%s). Output: %q", algo, text) }
             workerID, ok := extractID(text, "worker")
             if !ok { t.Fatalf("worker node ID not found (%s). Output: %q", algo, text) }
-            if !strings.Contains(text, fmt.Sprintf("%s --> %s", mainID, workerID)) {
+            // go worker() is a goroutine spawn, drawn as a thick arrow
+            if !strings.Contains(text, fmt.Sprintf("%s ==> %s", mainID, workerID)) {
                 t.Fatalf("Mermaid output missing edge main->worker (%s). Output snippet: %q", algo, text)
             }
         })
     }
-}
\ No newline at end of file
+}
+func TestSymbolCallsMaxDepFrontier(t *testing.T) {
+    request := mcp.CallToolRequest{
+        Params: mcp.CallToolParams{
+            Name: "callHierarchy",
+            Arguments: map[string]interface{}{
+                "moduleArgs": []string{"../fixtures/chain"},
+                "algo":       "static",
+                "nostd":      true,
+                "nointer":    false,
+                "symbol":     "main.main",
+                "direction":  "downstream",
+                "max_dep":    1,
+            },
+        },
+    }
+
+    result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+    if err != nil {
+        t.Fatalf("HandleCallgraphRequest failed: %v", err)
+    }
+    text := result.Content[0].(mcp.TextContent).Text
+
+    for _, name := range []string{"main", "handle", "direct"} {
+        if _, ok := extractID(text, name); !ok {
+            t.Fatalf("%s node missing within depth 1. Output: %q", name, text)
+        }
+    }
+    for _, name := range []string{"validate", "save", "store"} {
+        if _, ok := extractID(text, name); ok {
+            t.Fatalf("%s node is beyond depth 1 but was rendered. Output: %q", name, text)
+        }
+    }
+    handleID, _ := extractID(text, "handle")
+    if !strings.Contains(text, fmt.Sprintf(`%s -.-> %s_more_callees(["+2 more callees"])`, handleID, handleID)) {
+        t.Fatalf("missing frontier stub for handle. Output: %q", text)
+    }
+    if !strings.Contains(text, "classDef frontier") {
+        t.Fatalf("missing frontier style. Output: %q", text)
+    }
+}
+
+func TestSymbolCallsPerDirectionDepth(t *testing.T) {
+    request := mcp.CallToolRequest{
+        Params: mcp.CallToolParams{
+            Name: "callHierarchy",
+            Arguments: map[string]interface{}{
+                "moduleArgs":         []string{"../fixtures/chain"},
+                "algo":               "static",
+                "nostd":              true,
+                "nointer":            false,
+                "symbol":             "save",
+                "direction":          "both",
+                "max_dep_upstream":   2,
+                "max_dep_downstream": 0,
+                "output_format":      "json",
+            },
+        },
+    }
+
+    result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+    if err != nil {
+        t.Fatalf("HandleCallgraphRequest failed: %v", err)
+    }
+    var resp handlers.MCPCallgraphResponse
+    if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &resp); err != nil {
+        t.Fatalf("invalid JSON output: %v", err)
+    }
+    funcs := make(map[string]handlers.MCPCallgraphNode)
+    for _, n := range resp.Graph.Nodes {
+        funcs[n.Func] = n
+    }
+    for _, name := range []string{"main", "direct", "save", "store"} {
+        if _, ok := funcs[name]; !ok {
+            t.Fatalf("%s missing from traversal: %+v", name, resp.Graph.Nodes)
+        }
+    }
+    if n := funcs["main"]; n.MoreCallers != 0 || n.MoreCallees != 0 {
+        t.Fatalf("main should not be a frontier node: %+v", n)
+    }
+}
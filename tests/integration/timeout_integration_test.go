@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+func chainRequest(args map[string]interface{}) mcp.CallToolRequest {
+	args["moduleArgs"] = []string{"../fixtures/chain"}
+	args["algo"] = "static"
+	args["symbol"] = "main.main"
+	return mcp.CallToolRequest{Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: args}}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	result, err := handlers.HandleCallgraphRequest(context.Background(), chainRequest(map[string]interface{}{
+		"refresh":    true,
+		"timeout_ms": 1,
+	}))
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !result.IsError || !strings.Contains(text, "analysis timed out after phase") {
+		t.Fatalf("expected timeout error, got %q", text)
+	}
+
+	// The aborted build must not poison the cache
+	result, err = handlers.HandleCallgraphRequest(context.Background(), chainRequest(map[string]interface{}{}))
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error after timeout: %s", result.Content[0].(mcp.TextContent).Text)
+	}
+}
+
+func TestRequestDefaultTimeout(t *testing.T) {
+	handlers.SetDefaultTimeout(time.Millisecond)
+	defer handlers.SetDefaultTimeout(0)
+
+	result, err := handlers.HandleCallgraphRequest(context.Background(), chainRequest(map[string]interface{}{"refresh": true}))
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !result.IsError || !strings.Contains(text, "timed out") {
+		t.Fatalf("expected timeout error from the server default, got %q", text)
+	}
+
+	// timeout_ms overrides the default
+	result, err = handlers.HandleCallgraphRequest(context.Background(), chainRequest(map[string]interface{}{"timeout_ms": 60000}))
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error with timeout_ms: %s", result.Content[0].(mcp.TextContent).Text)
+	}
+}
+
+func TestRequestCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, call := range []func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		handlers.HandleCallgraphRequest,
+		handlers.HandleCallPathRequest,
+		handlers.HandleFindSymbolRequest,
+	} {
+		result, err := call(ctx, chainRequest(map[string]interface{}{
+			"refresh": true,
+			"from":    "main.main",
+			"to":      "main.store",
+			"query":   "store",
+		}))
+		if err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		if !result.IsError || !strings.Contains(text, `analysis cancelled after phase "loading packages"`) {
+			t.Errorf("expected cancellation error, got %q", text)
+		}
+	}
+}
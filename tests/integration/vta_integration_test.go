@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"callgraph-mcp/handlers"
+)
+
+// pluginEdges returns the edges from runAudio to the Run methods resolved by
+// algo, as "caller -> callee" with package paths stripped
+func pluginEdges(t *testing.T, args map[string]interface{}) map[string]string {
+	t.Helper()
+	result := callTool(t, "callHierarchy", handlers.HandleCallgraphRequest, map[string]interface{}{
+		"moduleArgs":    []string{"../fixtures/plugins"},
+		"nointer":       false,
+		"symbol":        "main.runAudio",
+		"output_format": "json",
+	}, args)
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	var resp handlers.MCPCallgraphResponse
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	strip := strings.NewReplacer("callgraph-mcp/tests/fixtures/plugins.", "")
+	edges := make(map[string]string)
+	for _, e := range resp.Graph.Edges {
+		edges[strip.Replace(e.Caller)+" -> "+strip.Replace(e.Callee)] = e.Algorithm
+	}
+	return edges
+}
+
+func TestVTAInterfaceDispatch(t *testing.T) {
+	const audio, video = "runAudio -> (Audio).Run", "runAudio -> (Video).Run"
+
+	rtaEdges := pluginEdges(t, map[string]interface{}{"algo": "rta"})
+	if rtaEdges[audio] != "rta" || rtaEdges[video] != "rta" {
+		t.Errorf("rta should resolve the call to both methods, got %v", rtaEdges)
+	}
+
+	for _, seed := range []string{"cha", "rta"} {
+		edges := pluginEdges(t, map[string]interface{}{"algo": "vta", "vta_seed": seed})
+		if edges[audio] != "vta" {
+			t.Errorf("vta (seed %s) lost the Audio call, got %v", seed, edges)
+		}
+		if _, ok := edges[video]; ok {
+			t.Errorf("vta (seed %s) should not resolve the call to Video.Run, got %v", seed, edges)
+		}
+		if edges["(Audio).Run -> decode"] != "vta" {
+			t.Errorf("vta (seed %s) should keep static calls, got %v", seed, edges)
+		}
+	}
+}
+
+func TestVTAInvalidSeed(t *testing.T) {
+	result, err := handlers.HandleCallgraphRequest(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: map[string]interface{}{
+			"moduleArgs": []string{"../fixtures/plugins"},
+			"algo":       "vta",
+			"vta_seed":   "static",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("HandleCallgraphRequest failed: %v", err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !result.IsError || !strings.Contains(text, "invalid vta_seed: static") {
+		t.Errorf("expected an invalid vta_seed error, got %q", text)
+	}
+}
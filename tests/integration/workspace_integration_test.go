@@ -0,0 +1,157 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"callgraph-mcp/handlers"
+)
+
+// allowRoots restricts analyses to roots for the duration of the test
+func allowRoots(t *testing.T, roots ...string) {
+	t.Helper()
+	if err := handlers.SetAllowedRoots(roots); err != nil {
+		t.Fatalf("SetAllowedRoots failed: %v", err)
+	}
+	t.Cleanup(func() { handlers.SetAllowedRoots(nil) })
+}
+
+func TestWorkspaceRoots(t *testing.T) {
+	chain, err := filepath.Abs("../fixtures/chain")
+	if err != nil {
+		t.Fatalf("Abs failed: %v", err)
+	}
+	// A symlink inside a root must not lead outside it
+	tmp := t.TempDir()
+	link := filepath.Join(tmp, "escape")
+	if err := os.Symlink(filepath.Join(filepath.Dir(chain), "simple"), link); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+	allowRoots(t, chain, tmp)
+
+	call := func(args map[string]interface{}) *mcp.CallToolResult {
+		t.Helper()
+		args["algo"] = "static"
+		request := mcp.CallToolRequest{Params: mcp.CallToolParams{Name: "callHierarchy", Arguments: args}}
+		result, err := handlers.HandleCallgraphRequest(context.Background(), request)
+		if err != nil {
+			t.Fatalf("HandleCallgraphRequest failed: %v", err)
+		}
+		return result
+	}
+
+	if result := call(map[string]interface{}{"dir": chain, "moduleArgs": []string{"./..."}}); result.IsError {
+		t.Fatalf("expected a request inside the root to succeed: %s", result.Content[0].(mcp.TextContent).Text)
+	}
+	if result := call(map[string]interface{}{"dir": chain, "moduleArgs": []string{"callgraph-mcp/tests/fixtures/chain"}}); result.IsError {
+		t.Fatalf("expected an import path inside the root to succeed: %s", result.Content[0].(mcp.TextContent).Text)
+	}
+
+	rejected := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"dir outside", map[string]interface{}{"dir": filepath.Dir(chain), "moduleArgs": []string{"./simple"}}, `dir "`},
+		{"relative escape", map[string]interface{}{"dir": chain, "moduleArgs": []string{"../simple"}}, `moduleArgs "../simple"`},
+		{"wildcard escape", map[string]interface{}{"dir": chain, "moduleArgs": []string{"../..."}}, `moduleArgs "../..."`},
+		{"file query", map[string]interface{}{"dir": chain, "moduleArgs": []string{"file=/etc/hosts"}}, `moduleArgs "file=/etc/hosts"`},
+		{"symlink dir", map[string]interface{}{"dir": link, "moduleArgs": []string{"."}}, `dir "` + link + `"`},
+		{"symlink pattern", map[string]interface{}{"dir": tmp, "moduleArgs": []string{"./escape"}}, `moduleArgs "./escape"`},
+		{"standard library", map[string]interface{}{"dir": chain, "moduleArgs": []string{"fmt"}}, "package fmt matched by moduleArgs"},
+		{"import path escape", map[string]interface{}{"dir": chain, "moduleArgs": []string{"callgraph-mcp/tests/fixtures/simple"}}, "package callgraph-mcp/tests/fixtures/simple matched by moduleArgs"},
+	}
+	for _, tc := range rejected {
+		result := call(tc.args)
+		text := result.Content[0].(mcp.TextContent).Text
+		if !result.IsError {
+			t.Errorf("%s: expected an error, got:\n%s", tc.name, text)
+			continue
+		}
+		if !strings.Contains(text, tc.want) || !strings.Contains(text, "outside the allowed roots") || !strings.Contains(text, handlers.RootsResourceURI) {
+			t.Errorf("%s: unexpected error: %s", tc.name, text)
+		}
+	}
+}
+
+func TestAuthTokenAndRootsResource(t *testing.T) {
+	chain, err := filepath.Abs("../fixtures/chain")
+	if err != nil {
+		t.Fatalf("Abs failed: %v", err)
+	}
+	allowRoots(t, chain)
+
+	mcpServer := server.NewMCPServer("callgraph-mcp", "test")
+	mcpServer.AddResource(mcp.NewResource(handlers.RootsResourceURI, "Allowed roots"), handlers.HandleRootsResource)
+	ts := httptest.NewServer(handlers.RequireToken([]string{"old-token", "s3cret"}, server.NewStreamableHTTPServer(mcpServer)))
+	defer ts.Close()
+
+	for _, header := range []map[string]string{
+		nil,
+		{"Authorization": "Bearer wrong"},
+		{"Authorization": "Basic s3cret"},
+		{"X-API-Key": "s3cre"},
+	} {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("headers %v: expected 401 with WWW-Authenticate, got %d", header, resp.StatusCode)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, header := range []map[string]string{
+		{"Authorization": "Bearer s3cret"},
+		{"X-API-Key": "old-token"},
+	} {
+		c, err := client.NewStreamableHttpClient(ts.URL, transport.WithHTTPHeaders(header))
+		if err != nil {
+			t.Fatalf("NewStreamableHttpClient failed: %v", err)
+		}
+		initRequest := mcp.InitializeRequest{}
+		initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+		initRequest.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+		if _, err := c.Initialize(ctx, initRequest); err != nil {
+			t.Fatalf("headers %v: Initialize failed: %v", header, err)
+		}
+
+		readRequest := mcp.ReadResourceRequest{}
+		readRequest.Params.URI = handlers.RootsResourceURI
+		result, err := c.ReadResource(ctx, readRequest)
+		if err != nil {
+			t.Fatalf("ReadResource failed: %v", err)
+		}
+		var roots struct {
+			Restricted bool     `json:"restricted"`
+			Roots      []string `json:"roots"`
+		}
+		if err := json.Unmarshal([]byte(result.Contents[0].(mcp.TextResourceContents).Text), &roots); err != nil {
+			t.Fatalf("invalid roots resource: %v", err)
+		}
+		if !roots.Restricted || len(roots.Roots) != 1 || filepath.Base(roots.Roots[0]) != "chain" {
+			t.Errorf("unexpected roots resource: %+v", roots)
+		}
+		c.Close()
+	}
+}